@@ -0,0 +1,66 @@
+package crockford
+
+import (
+	"encoding/base32"
+	"time"
+)
+
+// IDBuilder builds a composite ID from parts appended in order,
+// optionally followed by a checksum symbol over the bytes of all the parts.
+// An ID made WithTime, WithRandom, and WithChecksum can be read by SplitID.
+// The zero value is not usable; create one with NewIDBuilder.
+type IDBuilder struct {
+	e        *base32.Encoding
+	dst      []byte
+	body     []byte
+	checksum bool
+}
+
+// NewIDBuilder returns an IDBuilder that encodes with e.
+func NewIDBuilder(e *base32.Encoding) *IDBuilder {
+	return &IDBuilder{e: e}
+}
+
+// WithTime appends a timestamp as by AppendTime.
+func (b *IDBuilder) WithTime(t time.Time) *IDBuilder {
+	n := len(b.dst)
+	b.dst = AppendTime(b.e, t, b.dst)
+	return b.add(n)
+}
+
+// WithRandom appends random symbols as by AppendRandom.
+func (b *IDBuilder) WithRandom() *IDBuilder {
+	n := len(b.dst)
+	b.dst = AppendRandom(b.e, b.dst)
+	return b.add(n)
+}
+
+// WithMD5 appends the MD5 hash of src as by AppendMD5.
+func (b *IDBuilder) WithMD5(src []byte) *IDBuilder {
+	n := len(b.dst)
+	b.dst = AppendMD5(b.e, b.dst, src)
+	return b.add(n)
+}
+
+// WithChecksum makes Build end the ID with the checksum
+// of the bytes of every part, wherever it is called in the chain.
+func (b *IDBuilder) WithChecksum() *IDBuilder {
+	b.checksum = true
+	return b
+}
+
+// Build returns the ID built so far.
+func (b *IDBuilder) Build() string {
+	if !b.checksum {
+		return string(b.dst)
+	}
+	return string(append(b.dst[:len(b.dst):len(b.dst)], Checksum(b.body, isUpper(b.e))))
+}
+
+// add records the bytes encoded by the symbols of b.dst after n
+func (b *IDBuilder) add(n int) *IDBuilder {
+	b.body = grow(b.body, b.e.DecodedLen(len(b.dst)-n))
+	m, _ := b.e.Decode(b.body[len(b.body):cap(b.body)], b.dst[n:])
+	b.body = b.body[:len(b.body)+m]
+	return b
+}
@@ -0,0 +1,48 @@
+package crockford_test
+
+import (
+	"crypto/md5"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func ExampleIDBuilder() {
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	id := crockford.NewIDBuilder(crockford.Upper).
+		WithTime(when).
+		WithRandom().
+		WithChecksum().
+		Build()
+
+	t, _, checksumOK, err := crockford.SplitID(crockford.Upper, id)
+	fmt.Println(len(id), t.UTC(), checksumOK, err)
+	// Output:
+	// 17 2020-01-01 00:00:00 +0000 UTC true <nil>
+}
+
+func TestIDBuilder(t *testing.T) {
+	src := []byte("Hello, World!")
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := crockford.NewIDBuilder(crockford.Lower).WithChecksum().WithTime(when).WithMD5(src)
+	id := b.Build()
+	be.Equal(t, id, b.Build())
+	be.Equal(t, crockford.LenTime+crockford.LenMD5+1, len(id))
+	be.Equal(t, crockford.Time(crockford.Lower, when), id[:crockford.LenTime])
+	be.Equal(t, crockford.MD5(crockford.Lower, src), id[crockford.LenTime:len(id)-1])
+
+	var body []byte
+	tb, err := crockford.DecodeString(crockford.Lower, id[:crockford.LenTime])
+	be.NilErr(t, err)
+	sum := md5.Sum(src)
+	body = append(append(body, tb...), sum[:]...)
+	be.Equal(t, crockford.Checksum(body, false), id[len(id)-1])
+
+	plain := crockford.NewIDBuilder(crockford.Upper).WithTime(when).Build()
+	be.Equal(t, crockford.Time(crockford.Upper, when), plain)
+	be.Equal(t, "", crockford.NewIDBuilder(crockford.Upper).Build())
+	be.Equal(t, "0", crockford.NewIDBuilder(crockford.Upper).WithChecksum().Build())
+}
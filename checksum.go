@@ -0,0 +1,272 @@
+package crockford
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// HasValidChecksum reports whether the last symbol of s
+// is the checksum of the decoded body preceding it.
+// s is normalized first.
+func HasValidChecksum(e *base32.Encoding, s string) bool {
+	b := AppendNormalizedFor(nil, []byte(s), decodeAlphabet(e))
+	return validChecksum(e, b)
+}
+
+// validChecksum is HasValidChecksum for normalized b
+func validChecksum(e *base32.Encoding, b []byte) bool {
+	if len(b) < 1 {
+		return false
+	}
+	body, check := b[:len(b)-1], b[len(b)-1]
+	dbuf := make([]byte, e.DecodedLen(len(body)))
+	n, err := e.Decode(dbuf, body)
+	if err != nil {
+		return false
+	}
+	return Checksum(dbuf[:n], isUpper(e)) == check
+}
+
+// SuggestCorrections returns the strings, normalized, that differ from s
+// by a single symbol substitution and have a valid checksum,
+// for "did you mean" prompts on mistyped IDs.
+// If s is already valid, it returns only s normalized.
+// The checksum detects any single substitution but cannot locate it,
+// so for an ID of LenID or LenUUID symbols, expect a dozen or so
+// candidates. One of them always keeps the body and replaces the checksum
+// symbol, so the result is most useful when filtered against known IDs.
+// Substitutions that only change the unused low bits of the last
+// body symbol are not counted.
+// It tries up to 37 symbols at each position, decoding once per try.
+func SuggestCorrections(e *base32.Encoding, s string) []string {
+	alphabet := decodeAlphabet(e)
+	b := AppendNormalizedFor(nil, []byte(s), alphabet)
+	if validChecksum(e, b) {
+		return []string{string(b)}
+	}
+	var candidates []string
+	for i, orig := range b {
+		symbols := alphabet[:32]
+		if i == len(b)-1 {
+			symbols = alphabet
+		}
+		for j := 0; j < len(symbols); j++ {
+			if symbols[j] == orig {
+				continue
+			}
+			b[i] = symbols[j]
+			if validChecksum(e, b) && isCanonicalBody(e, b[:len(b)-1]) {
+				candidates = append(candidates, string(b))
+			}
+		}
+		b[i] = orig
+	}
+	return candidates
+}
+
+// isCanonicalBody reports whether body is the encoding of its decoded bytes,
+// with the unused low bits of its last symbol clear
+func isCanonicalBody(e *base32.Encoding, body []byte) bool {
+	decoded, err := AppendDecoded(e, nil, body)
+	return err == nil && string(Append(e, nil, decoded)) == string(body)
+}
+
+// DecodeIgnoreChecksum decodes s after removing a trailing checksum symbol,
+// without verifying it. It is for read paths that trust checksums
+// were verified when the data was written.
+// The last symbol is removed if it is one of the checksum-only symbols
+// * ~ $ = U, or if s has a length that only a body and checksum can have,
+// which is the case when the body encodes a multiple of 5 bytes, or 1 or 3 more.
+// Otherwise a checksum that is also a data symbol cannot be told apart
+// from the data, and s is decoded as is.
+func DecodeIgnoreChecksum(e *base32.Encoding, s string) ([]byte, error) {
+	alphabet := decodeAlphabet(e)
+	b := AppendNormalizedFor(nil, []byte(s), alphabet)
+	n := len(b)
+	if n > 0 && (strings.IndexByte(alphabet, b[n-1]) >= 32 || !validLen(n) && validLen(n-1)) {
+		b = b[:n-1]
+	}
+	return AppendDecoded(e, nil, b)
+}
+
+// Rechecksum replaces the trailing checksum symbol of s,
+// which may be stale after the body was edited, with the checksum
+// of the decoded body. The result is the normalized body followed
+// by the new checksum symbol. s must end in a checksum symbol,
+// as produced by ChecksumEncoding; use Checksum to add one to a bare body.
+// It returns an error if the body cannot be decoded.
+func Rechecksum(e *base32.Encoding, s string) (string, error) {
+	b := AppendNormalizedFor(nil, []byte(s), decodeAlphabet(e))
+	if len(b) < 1 {
+		return "", fmt.Errorf("%w: missing checksum", ErrInvalidLength)
+	}
+	body := b[:len(b)-1]
+	decoded, err := AppendDecoded(e, nil, body)
+	if err != nil {
+		return "", err
+	}
+	return string(append(body, Checksum(decoded, isUpper(e)))), nil
+}
+
+// AppendVersionedChecksummed appends onto dst the encoding of
+// version followed by payload, then the checksum symbol of those bytes.
+// DecodeVersionedChecksummed reverses it.
+func AppendVersionedChecksummed(e *base32.Encoding, version byte, payload []byte, dst []byte) []byte {
+	body := make([]byte, 0, 1+len(payload))
+	body = append(body, version)
+	body = append(body, payload...)
+	return NewChecksumEncoding(e).AppendEncode(dst, body)
+}
+
+// DecodeVersionedChecksummed verifies the checksum of s,
+// as created by AppendVersionedChecksummed, and returns
+// its version byte and payload. It returns ErrChecksumMismatch
+// if the checksum does not match and ErrInvalidLength
+// if there is no version byte. Callers should check that
+// the version is one they understand.
+func DecodeVersionedChecksummed(e *base32.Encoding, s string) (version byte, payload []byte, err error) {
+	body, err := NewChecksumEncoding(e).DecodeString(s)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(body) < 1 {
+		return 0, nil, fmt.Errorf("%w: missing version", ErrInvalidLength)
+	}
+	return body[0], body[1:], nil
+}
+
+// AppendRandomWithChecksumAt appends onto dst the encoding of rawBytes
+// bytes from crypto/rand with their checksum symbol inserted at
+// index pos of the output, for fixed layouts where the check symbol
+// is not last. pos may be from 0, before the first symbol, to
+// e.EncodedLen(rawBytes), after the last; it panics otherwise.
+// VerifyChecksumAt checks the result.
+func AppendRandomWithChecksumAt(e *base32.Encoding, rawBytes, pos int, dst []byte) []byte {
+	if rawBytes < 0 {
+		panic("invalid length")
+	}
+	n := e.EncodedLen(rawBytes)
+	if pos < 0 || pos > n {
+		panic("invalid position")
+	}
+	raw := make([]byte, rawBytes)
+	if _, err := rand.Read(raw); err != nil {
+		panic(err)
+	}
+	start := len(dst)
+	dst = Append(e, grow(dst, n+1), raw)
+	dst = append(dst, 0)
+	copy(dst[start+pos+1:], dst[start+pos:])
+	dst[start+pos] = Checksum(raw, isUpper(e))
+	return dst
+}
+
+// VerifyChecksumAt reports whether the symbol at index pos of
+// normalized s is the checksum of the body made of the other symbols,
+// as produced by AppendRandomWithChecksumAt.
+// It returns false if pos is out of range or the body cannot be decoded.
+func VerifyChecksumAt(e *base32.Encoding, s string, pos int) bool {
+	b := AppendNormalizedFor(nil, []byte(s), decodeAlphabet(e))
+	if pos < 0 || pos >= len(b) {
+		return false
+	}
+	check := b[pos]
+	body := append(b[:pos:pos], b[pos+1:]...)
+	decoded, err := AppendDecoded(e, nil, body)
+	return err == nil && Checksum(decoded, isUpper(e)) == check
+}
+
+// Checksummer computes a checksum incrementally.
+// It implements io.Writer, and its Sum after writing a body in any number of
+// pieces equals Checksum of the whole body.
+// The zero value is ready to use.
+type Checksummer struct {
+	rem int
+}
+
+// Write adds p to the running checksum. It never returns an error.
+func (ck *Checksummer) Write(p []byte) (int, error) {
+	for _, c := range p {
+		ck.rem = (ck.rem<<8 + int(c)) % 37
+	}
+	return len(p), nil
+}
+
+// Sum returns the checksum symbol of the bytes written so far.
+func (ck *Checksummer) Sum(uppercase bool) byte {
+	return Alphabet(uppercase, true)[ck.rem]
+}
+
+// Reset discards the bytes written so far.
+func (ck *Checksummer) Reset() {
+	ck.rem = 0
+}
+
+// VerifyChecksumStream reports whether expected is the checksum symbol
+// of all the bytes read from r, without holding them in memory.
+// expected is normalized first, so either case is accepted.
+// It returns any error from reading r.
+func VerifyChecksumStream(e *base32.Encoding, r io.Reader, expected byte) (bool, error) {
+	var ck Checksummer
+	if _, err := io.Copy(&ck, r); err != nil {
+		return false, err
+	}
+	want := AppendNormalizedFor(nil, []byte{expected}, decodeAlphabet(e))
+	return len(want) == 1 && ck.Sum(isUpper(e)) == want[0], nil
+}
+
+// ChecksumEncoding is an encoding that appends a checksum symbol
+// when encoding and verifies and removes it when decoding.
+type ChecksumEncoding struct {
+	e *base32.Encoding
+}
+
+// Checksummed encodings
+var (
+	UpperCk = NewChecksumEncoding(Upper)
+	LowerCk = NewChecksumEncoding(Lower)
+)
+
+// NewChecksumEncoding returns a ChecksumEncoding built on e.
+func NewChecksumEncoding(e *base32.Encoding) *ChecksumEncoding {
+	return &ChecksumEncoding{e}
+}
+
+// EncodedLen returns the length of the encoding of n bytes,
+// including the checksum symbol.
+func (enc *ChecksumEncoding) EncodedLen(n int) int {
+	return enc.e.EncodedLen(n) + 1
+}
+
+// AppendEncode appends the encoding of src and its checksum symbol onto dst.
+func (enc *ChecksumEncoding) AppendEncode(dst, src []byte) []byte {
+	dst = Append(enc.e, dst, src)
+	return append(dst, Checksum(src, isUpper(enc.e)))
+}
+
+// EncodeToString returns the encoding of src followed by its checksum symbol.
+func (enc *ChecksumEncoding) EncodeToString(src []byte) string {
+	return string(enc.AppendEncode(nil, src))
+}
+
+// DecodeString normalizes s, verifies its trailing checksum symbol,
+// and returns the decoded body. It returns ErrChecksumMismatch
+// if the checksum does not match.
+func (enc *ChecksumEncoding) DecodeString(s string) ([]byte, error) {
+	b := AppendNormalizedFor(nil, []byte(s), decodeAlphabet(enc.e))
+	if len(b) < 1 {
+		return nil, fmt.Errorf("%w: missing checksum", ErrInvalidLength)
+	}
+	body, check := b[:len(b)-1], b[len(b)-1]
+	decoded, err := AppendDecoded(enc.e, nil, body)
+	if err != nil {
+		return nil, err
+	}
+	if Checksum(decoded, isUpper(enc.e)) != check {
+		return nil, ErrChecksumMismatch
+	}
+	return decoded, nil
+}
@@ -0,0 +1,329 @@
+package crockford_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func TestHasValidChecksum(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want bool
+	}{
+		{"", false},
+		{"-", false},
+		{"0", true},
+		{"*", false},
+		{"D1JPRV3F41VPYWKCCG", false},
+		{"D1JPRV3F41VPYWKCCG" + string(crockford.Checksum([]byte("hello world"), true)), true},
+		{"d1jp-rv3f-41vp-ywkc-cg" + string(crockford.Checksum([]byte("hello world"), false)), true},
+		{"ZZZZZZZZ" + string(crockford.Checksum([]byte("\xff\xff\xff\xff\xff"), true)), true},
+		{"ZZZZZZZZ*", false},
+		{"ZZZZZZZZU", false},
+		{"ZZZZZZZZ0", false},
+		{"Z*ZZZZZZ" + string(crockford.Checksum([]byte("\xff\xff\xff\xff\xff"), true)), false},
+	} {
+		be.Equal(t, tc.want, crockford.HasValidChecksum(crockford.Upper, tc.in))
+	}
+}
+
+func TestChecksummer(t *testing.T) {
+	payload := bytes.Repeat([]byte("Hello, World!"), 100)
+	for _, size := range []int{1, 2, 3, 7, 64, len(payload)} {
+		var ck crockford.Checksummer
+		for rest := payload; len(rest) > 0; {
+			n := size
+			if n > len(rest) {
+				n = len(rest)
+			}
+			_, _ = ck.Write(rest[:n])
+			rest = rest[n:]
+		}
+		be.Equal(t, crockford.Checksum(payload, true), ck.Sum(true))
+		be.Equal(t, crockford.Checksum(payload, false), ck.Sum(false))
+	}
+
+	var ck crockford.Checksummer
+	be.Equal(t, '0', ck.Sum(true))
+	_, _ = io.Copy(io.MultiWriter(&ck, io.Discard), bytes.NewReader(payload))
+	be.Equal(t, crockford.Checksum(payload, true), ck.Sum(true))
+	ck.Reset()
+	be.Equal(t, '0', ck.Sum(true))
+}
+
+func TestSuggestCorrections(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{"000", []string{"000"}},
+		{"", nil},
+	} {
+		be.AllEqual(t, tc.want, crockford.SuggestCorrections(crockford.Upper, tc.in))
+	}
+	be.True(t, contains(crockford.SuggestCorrections(crockford.Upper, "00D"), "000"))
+	be.True(t, contains(crockford.SuggestCorrections(crockford.Upper, "zw4"), "ZW~"))
+	be.True(t, contains(crockford.SuggestCorrections(crockford.Upper, "A0X"), "A06"))
+	// ZZ has unused low bits set, so it is not offered
+	be.False(t, contains(crockford.SuggestCorrections(crockford.Upper, "zz4"), "ZZ~"))
+
+	// realistic IDs: the intended ID is among a few candidates
+	for _, size := range []int{5 + 5, 16} {
+		for i := 0; i < 50; i++ {
+			body := make([]byte, size)
+			_, err := rand.Read(body)
+			be.NilErr(t, err)
+			id := crockford.UpperCk.EncodeToString(body)
+			if size == 10 {
+				be.Equal(t, crockford.LenID, len(id))
+			} else {
+				be.Equal(t, crockford.LenUUID+1, len(id))
+			}
+			typo := []byte(id)
+			pos := i % (len(id) - 2)
+			typo[pos] = crockford.UppercaseAlphabet[(strings.IndexByte(crockford.UppercaseAlphabet, typo[pos])+1)%32]
+
+			candidates := crockford.SuggestCorrections(crockford.Upper, string(typo))
+			be.True(t, contains(candidates, id))
+			be.True(t, len(candidates) < 40)
+			for _, c := range candidates {
+				be.True(t, crockford.HasValidChecksum(crockford.Upper, c))
+			}
+			// fixing the checksum symbol is always a candidate
+			fixed, err := crockford.Rechecksum(crockford.Upper, string(typo))
+			be.NilErr(t, err)
+			be.True(t, contains(candidates, fixed))
+		}
+	}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestChecksumEncoding(t *testing.T) {
+	for _, in := range []string{"", "\x00", "hello", "hello world", "\xff\xff\xff\xff\xff"} {
+		for _, enc := range []*crockford.ChecksumEncoding{crockford.UpperCk, crockford.LowerCk} {
+			s := enc.EncodeToString([]byte(in))
+			be.Equal(t, enc.EncodedLen(len(in)), len(s))
+			be.True(t, crockford.HasValidChecksum(crockford.Upper, s))
+
+			got, err := enc.DecodeString(s)
+			be.NilErr(t, err)
+			be.Equal(t, in, string(got))
+			got, err = enc.DecodeString(crockford.Partition(crockford.ToUpper(s), 4))
+			be.NilErr(t, err)
+			be.Equal(t, in, string(got))
+		}
+	}
+	s := crockford.UpperCk.EncodeToString([]byte("hello world"))
+	be.Equal(t, "D1JPRV3F41VPYWKCCG"+string(crockford.Checksum([]byte("hello world"), true)), s)
+
+	// tampering
+	tampered := []byte(s)
+	tampered[0] = 'E'
+	_, err := crockford.UpperCk.DecodeString(string(tampered))
+	be.True(t, errors.Is(err, crockford.ErrChecksumMismatch))
+	tampered = []byte(s)
+	tampered[len(tampered)-1] = '*'
+	if s[len(s)-1] == '*' {
+		tampered[len(tampered)-1] = '~'
+	}
+	_, err = crockford.UpperCk.DecodeString(string(tampered))
+	be.True(t, errors.Is(err, crockford.ErrChecksumMismatch))
+
+	_, err = crockford.UpperCk.DecodeString("")
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+	_, err = crockford.UpperCk.DecodeString(s[:len(s)-1])
+	be.Nonzero(t, err)
+}
+
+func TestChecksumPadded(t *testing.T) {
+	padded := base32.NewEncoding(crockford.UppercaseAlphabet)
+	s := crockford.NewChecksumEncoding(padded).EncodeToString([]byte("hello"))
+	be.Equal(t, "D1JPRV3F"+string(crockford.Checksum([]byte("hello"), true)), s)
+	be.True(t, crockford.HasValidChecksum(padded, s))
+	got, err := crockford.NewChecksumEncoding(padded).DecodeString(s)
+	be.NilErr(t, err)
+	be.Equal(t, "hello", string(got))
+
+	c, err := crockford.ChecksumString(base32.StdEncoding, "D1JPRV3F")
+	be.NilErr(t, err)
+	be.Equal(t, crockford.Checksum([]byte("hello"), true), c)
+	c, err = crockford.ChecksumString(base32.NewEncoding(crockford.LowercaseAlphabet), "d1jprv3f")
+	be.NilErr(t, err)
+	be.Equal(t, crockford.Checksum([]byte("hello"), false), c)
+}
+
+func TestDecodeIgnoreChecksum(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"", ""},
+		{"*", ""},
+		{"0", ""},
+		{"D1JPRV3F41VPYWKCCG", "hello world"},
+		{"D1JPRV3F41VPYWKCCG*", "hello world"},
+		{"d1jp-rv3f-41vp-ywkc-cg-u", "hello world"},
+		{"ZZZZZZZZ=", "\xff\xff\xff\xff\xff"},
+		{"ZZZZZZZZ$", "\xff\xff\xff\xff\xff"},
+		{"ZZZZZZZZ~", "\xff\xff\xff\xff\xff"},
+		// checksums that are data symbols
+		{"D1JPRV3FJ", "hello"},
+		{"ZZZZZZZZ7", "\xff\xff\xff\xff\xff"},
+		// plain inputs
+		{"ZZZZZZZZ", "\xff\xff\xff\xff\xff"},
+		{"D1JPRV3F", "hello"},
+		{"d1jp-rv3f-41vp-ywkc-cg", "hello world"},
+	} {
+		got, err := crockford.DecodeIgnoreChecksum(crockford.Upper, tc.in)
+		be.NilErr(t, err)
+		be.Equal(t, tc.want, string(got))
+	}
+	for i := 0; i < 1000; i++ {
+		var payload [5]byte
+		binary.BigEndian.PutUint32(payload[1:], uint32(i*2654435761))
+		got, err := crockford.DecodeIgnoreChecksum(crockford.Upper, crockford.UpperCk.EncodeToString(payload[:]))
+		be.NilErr(t, err)
+		be.Equal(t, string(payload[:]), string(got))
+	}
+	// only one checksum symbol is removed
+	_, err := crockford.DecodeIgnoreChecksum(crockford.Upper, "ZZZZZZZ**")
+	be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+	// a wrong checksum is not noticed
+	got, err := crockford.DecodeIgnoreChecksum(crockford.Lower, crockford.UpperCk.EncodeToString([]byte("hi"))[:4]+"*")
+	be.NilErr(t, err)
+	be.Equal(t, "hi", string(got))
+}
+
+func TestAppendVersionedChecksummed(t *testing.T) {
+	for _, payload := range []string{"", "x", "hello world"} {
+		for _, version := range []byte{0, 1, 255} {
+			s := crockford.AppendVersionedChecksummed(crockford.Upper, version, []byte(payload), nil)
+			be.True(t, crockford.HasValidChecksum(crockford.Upper, string(s)))
+
+			gotV, gotP, err := crockford.DecodeVersionedChecksummed(crockford.Lower, crockford.ToLower(string(s)))
+			be.NilErr(t, err)
+			be.Equal(t, version, gotV)
+			be.Equal(t, payload, string(gotP))
+		}
+	}
+	s := crockford.AppendVersionedChecksummed(crockford.Upper, 1, []byte("hello world"), nil)
+	v2 := crockford.AppendVersionedChecksummed(crockford.Upper, 2, []byte("hello world"), nil)
+	be.Unequal(t, string(s), string(v2))
+
+	// tampered payload
+	tampered := append([]byte(nil), s...)
+	tampered[5] = 'Z'
+	if s[5] == 'Z' {
+		tampered[5] = 'Y'
+	}
+	_, _, err := crockford.DecodeVersionedChecksummed(crockford.Upper, string(tampered))
+	be.True(t, errors.Is(err, crockford.ErrChecksumMismatch))
+
+	// swapping the version body onto another checksum fails
+	mixed := string(v2[:2]) + string(s[2:])
+	_, _, err = crockford.DecodeVersionedChecksummed(crockford.Upper, mixed)
+	be.True(t, errors.Is(err, crockford.ErrChecksumMismatch))
+
+	_, _, err = crockford.DecodeVersionedChecksummed(crockford.Upper, "0")
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+}
+
+func TestVerifyChecksumStream(t *testing.T) {
+	data := bytes.Repeat([]byte("large payload "), 10_000)
+	want := crockford.Checksum(data, true)
+
+	ok, err := crockford.VerifyChecksumStream(crockford.Upper, iotest.OneByteReader(bytes.NewReader(data)), want)
+	be.NilErr(t, err)
+	be.True(t, ok)
+
+	lower := crockford.Checksum(data, false)
+	ok, err = crockford.VerifyChecksumStream(crockford.Upper, bytes.NewReader(data), lower)
+	be.NilErr(t, err)
+	be.True(t, ok)
+
+	ok, err = crockford.VerifyChecksumStream(crockford.Lower, bytes.NewReader(data[1:]), lower)
+	be.NilErr(t, err)
+	be.False(t, ok)
+
+	ok, err = crockford.VerifyChecksumStream(crockford.Upper, bytes.NewReader(data), '-')
+	be.NilErr(t, err)
+	be.False(t, ok)
+
+	errRead := errors.New("read failed")
+	_, err = crockford.VerifyChecksumStream(crockford.Upper, iotest.ErrReader(errRead), want)
+	be.True(t, errors.Is(err, errRead))
+}
+
+func TestRechecksum(t *testing.T) {
+	s := crockford.UpperCk.EncodeToString([]byte("version 1"))
+	got, err := crockford.Rechecksum(crockford.Upper, s)
+	be.NilErr(t, err)
+	be.Equal(t, s, got)
+
+	// bump the body and recompute the stale checksum
+	edited := crockford.Upper.EncodeToString([]byte("version 2")) + s[len(s)-1:]
+	be.False(t, crockford.HasValidChecksum(crockford.Upper, edited))
+	got, err = crockford.Rechecksum(crockford.Upper, strings.ToLower(edited))
+	be.NilErr(t, err)
+	be.Equal(t, crockford.UpperCk.EncodeToString([]byte("version 2")), got)
+	be.True(t, crockford.HasValidChecksum(crockford.Upper, got))
+
+	got, err = crockford.Rechecksum(crockford.Lower, edited)
+	be.NilErr(t, err)
+	be.Equal(t, crockford.LowerCk.EncodeToString([]byte("version 2")), got)
+
+	for _, in := range []string{"", "0*0", "*0"} {
+		_, err = crockford.Rechecksum(crockford.Upper, in)
+		be.Nonzero(t, err)
+	}
+}
+
+func TestAppendRandomWithChecksumAt(t *testing.T) {
+	for _, e := range []*base32.Encoding{crockford.Upper, crockford.Lower} {
+		for pos := 0; pos <= 8; pos++ {
+			s := crockford.AppendRandomWithChecksumAt(e, 5, pos, []byte("x"))
+			be.Equal(t, byte('x'), s[0])
+			id := string(s[1:])
+			be.Equal(t, 9, len(id))
+			be.True(t, crockford.VerifyChecksumAt(e, id, pos))
+			be.True(t, crockford.VerifyChecksumAt(e, crockford.Partition(id, 3), pos))
+
+			body := id[:pos] + id[pos+1:]
+			decoded, err := crockford.DecodeString(e, body)
+			be.NilErr(t, err)
+			be.Equal(t, crockford.Checksum(decoded, e == crockford.Upper), id[pos])
+		}
+	}
+	s := string(crockford.AppendRandomWithChecksumAt(crockford.Upper, 10, 4, nil))
+	be.False(t, crockford.VerifyChecksumAt(crockford.Upper, s, -1))
+	be.False(t, crockford.VerifyChecksumAt(crockford.Upper, s, len(s)))
+	// a changed symbol fails
+	c := byte('0')
+	if s[0] == c {
+		c = '1'
+	}
+	be.False(t, crockford.VerifyChecksumAt(crockford.Upper, string(c)+s[1:], 4))
+
+	be.Equal(t, "0", string(crockford.AppendRandomWithChecksumAt(crockford.Upper, 0, 0, nil)))
+	for _, pos := range []int{-1, 9} {
+		func() {
+			defer func() { be.Nonzero(t, recover()) }()
+			crockford.AppendRandomWithChecksumAt(crockford.Upper, 5, pos, nil)
+		}()
+	}
+}
@@ -1,12 +1,35 @@
 // Package crockford implements the Crockford base 32 encoding
 //
 // See https://www.crockford.com/base32.html
+//
+// AppendTime, AppendUint64, and the other big endian encoders
+// produce strings that sort lexicographically in numeric order.
+// Little endian encoders such as AppendUint64LE do not.
+//
+// The specification describes encoding numbers, zero-extended at the front
+// to a multiple of 5 bits. Append and the other byte encoders instead follow
+// encoding/base32 and pad byte strings with zero bits at the end,
+// so the two only agree when the byte length is a multiple of 5.
+// Likewise, Checksum treats its body as a big endian number of whole bytes.
+// AppendNumber, ValueBits, and ChecksumString follow the specification's
+// numeric definitions exactly.
 package crockford
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,38 +41,434 @@ const (
 	LowercaseChecksum = LowercaseAlphabet + "*~$=u"
 )
 
+// Alphabet returns the alphabet constant for the given case,
+// including the checksum symbols if withChecksum is set.
+func Alphabet(upper, withChecksum bool) string {
+	switch {
+	case upper && withChecksum:
+		return UppercaseChecksum
+	case upper:
+		return UppercaseAlphabet
+	case withChecksum:
+		return LowercaseChecksum
+	}
+	return LowercaseAlphabet
+}
+
 // Base32 encodings
 var (
 	Lower = base32.NewEncoding(LowercaseAlphabet).WithPadding(base32.NoPadding)
 	Upper = base32.NewEncoding(UppercaseAlphabet).WithPadding(base32.NoPadding)
 )
 
+// Codec is the string encoding and decoding shared by *base32.Encoding,
+// including Upper and Lower, and *ChecksumEncoding, including UpperCk and LowerCk.
+// Code that accepts a Codec can be given any of them, or a fake in tests.
+// Note that the DecodeString method of *base32.Encoding does not normalize;
+// use *ChecksumEncoding or the package level DecodeString for user input.
+type Codec interface {
+	EncodeToString(src []byte) string
+	DecodeString(s string) ([]byte, error)
+}
+
+var (
+	_ Codec = (*base32.Encoding)(nil)
+	_ Codec = (*ChecksumEncoding)(nil)
+)
+
 // Buffer lengths
 const (
-	LenTime   = 8  // length returned by AppendTime
-	LenRandom = 8  // length returned by AppendRandom
-	LenMD5    = 26 // length returned by AppendMD5
+	LenTime     = 8  // length returned by AppendTime
+	LenTime48   = 10 // length returned by AppendTime48
+	LenRandom   = 8  // length returned by AppendRandom
+	LenMD5      = 26 // length returned by AppendMD5
+	LenBytes16  = 26 // length returned by AppendBytes16
+	LenUUID     = 26 // length returned by AppendUUIDString
+	LenSHA256   = 52 // length returned by a Hasher using SHA-256
+	LenDuration = 13 // length returned by AppendDuration
+	LenUint64   = 13 // length returned by AppendUint64 and AppendUint64LE
+	LenFullTime = 20 // length returned by AppendFullTime
+	LenChecksum = 1  // length of a checksum symbol
 )
 
+// NewBuffer returns an empty slice with room for the sum of lengths,
+// which are typically the Len constants of the parts of a composite ID.
+// For example, NewBuffer(LenTime, LenRandom, LenChecksum) holds a time,
+// random bytes, and a checksum, so appending them never reallocates.
+func NewBuffer(lengths ...int) []byte {
+	n := 0
+	for _, l := range lengths {
+		n += l
+	}
+	return make([]byte, 0, n)
+}
+
+// TimeUnit identifies the timestamp layout of a group of time encoders.
+type TimeUnit int
+
+// Timestamp layouts
+const (
+	// TimeSeconds40 is the 40-bit Unix seconds of AppendTime,
+	// AppendTimeRandom, and AppendObfuscatedTime.
+	TimeSeconds40 TimeUnit = iota
+	// TimeSeconds48 is the 48-bit Unix seconds of AppendTime48.
+	TimeSeconds48
+	// TimeMillis48 is the 48-bit Unix milliseconds of Sequencer,
+	// AtomicSequencer, and ULIDs.
+	TimeMillis48
+)
+
+// MaxTime returns the latest time that the encoders using unit can represent.
+// Later times wrap around and decode to early times, so callers
+// can compare against it before encoding. For example,
+// MaxTime(TimeSeconds40) is in the year 36812.
+// It panics for an unknown unit.
+func MaxTime(unit TimeUnit) time.Time {
+	switch unit {
+	case TimeSeconds40:
+		return time.Unix(maxUint40, 0)
+	case TimeSeconds48:
+		return time.Unix(1<<48-1, 0)
+	case TimeMillis48:
+		return time.UnixMilli(1<<48 - 1)
+	}
+	panic("invalid time unit")
+}
+
 // Time encodes the Unix time as a 40-bit number. The resulting string is big endian
 // and suitable for lexicographic sorting.
 func Time(e *base32.Encoding, t time.Time) string {
-	return string(AppendTime(e, t, nil))
+	// encode on the stack, so the string is the only allocation
+	var buf [LenTime]byte
+	return string(AppendTime(e, t, buf[:0]))
+}
+
+// TimePrefix returns the longest prefix shared by the AppendTime encodings
+// of every second in the bucket containing t, as given by t.Truncate(bucket).
+// Any ID that starts with AppendTime for a time in the bucket has this prefix,
+// so it can bound a prefix range scan, such as all IDs from one hour.
+// Each symbol covers five bits of the 40-bit time, so unless the bucket
+// is aligned to a power of 32 seconds, the prefix also matches some
+// neighboring times, and results should be filtered by their decoded time.
+// It panics if bucket is less than a second.
+func TimePrefix(e *base32.Encoding, t time.Time, bucket time.Duration) string {
+	if bucket < time.Second {
+		panic("invalid bucket")
+	}
+	start := t.Truncate(bucket)
+	var buf [2 * LenTime]byte
+	first := AppendTime(e, start, buf[:0])
+	last := AppendTime(e, start.Add(bucket-time.Second), buf[LenTime:LenTime])
+	n := 0
+	for n < LenTime && first[n] == last[n] {
+		n++
+	}
+	return string(first[:n])
 }
 
 // AppendTime appends onto dst LenTime bytes with the Unix time encoded as a 40-bit number.
 // The resulting slice is big endian and suitable for lexicographic sorting.
 func AppendTime(e *base32.Encoding, t time.Time, dst []byte) []byte {
-	ut := t.Unix()
 	var src [5]byte
-	src[0] = byte(ut >> 32)
-	src[1] = byte(ut >> 24)
-	src[2] = byte(ut >> 16)
-	src[3] = byte(ut >> 8)
-	src[4] = byte(ut)
+	putTime40(&src, t.Unix())
+	return appendN(e, LenTime, dst, src[:])
+}
+
+// putTime40 writes the low 40 bits of v into src, big endian
+func putTime40(src *[5]byte, v int64) {
+	src[0] = byte(v >> 32)
+	src[1] = byte(v >> 24)
+	src[2] = byte(v >> 16)
+	src[3] = byte(v >> 8)
+	src[4] = byte(v)
+}
+
+// time40 reverses putTime40
+func time40(src []byte) int64 {
+	return int64(src[0])<<32 |
+		int64(src[1])<<24 |
+		int64(src[2])<<16 |
+		int64(src[3])<<8 |
+		int64(src[4])
+}
+
+// maxUint40 is the largest time AppendTime can encode
+const maxUint40 = 1<<40 - 1
+
+// AppendTimeDescending is like AppendTime, but encodes maxUint40 minus
+// the Unix time, so that later times sort first.
+// DecodeTimeDescending reverses it.
+func AppendTimeDescending(e *base32.Encoding, t time.Time, dst []byte) []byte {
+	var src [5]byte
+	putTime40(&src, maxUint40-t.Unix())
+	return appendN(e, LenTime, dst, src[:])
+}
+
+// DecodeTimeDescending decodes a time encoded by AppendTimeDescending.
+// It returns ErrInvalidLength unless s decodes to 5 bytes,
+// which also keeps the value within 40 bits.
+func DecodeTimeDescending(e *base32.Encoding, s string) (time.Time, error) {
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(b) != 5 {
+		return time.Time{}, lenError(len(b), 5)
+	}
+	return time.Unix(maxUint40-time40(b), 0), nil
+}
+
+// AppendObfuscatedTime is like AppendTime, but XORs the 5 time bytes
+// with a keystream derived from key with HMAC-SHA256 before encoding.
+// The result does not reveal the time or sort chronologically
+// unless you hold the key. This is obfuscation, not encryption:
+// the keystream is the same for every time, so XORing two results
+// reveals how their times differ.
+func AppendObfuscatedTime(e *base32.Encoding, t time.Time, key []byte, dst []byte) []byte {
+	var src [5]byte
+	putTime40(&src, t.Unix())
+	xorTimeKey(src[:], key)
 	return appendN(e, LenTime, dst, src[:])
 }
 
+// DecodeObfuscatedTime decodes a time encoded by AppendObfuscatedTime with key.
+func DecodeObfuscatedTime(e *base32.Encoding, s string, key []byte) (time.Time, error) {
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(b) != 5 {
+		return time.Time{}, lenError(len(b), 5)
+	}
+	xorTimeKey(b, key)
+	return decodeTime(b), nil
+}
+
+func xorTimeKey(b, key []byte) {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("crockford obfuscated time"))
+	stream := mac.Sum(nil)
+	for i := range b {
+		b[i] ^= stream[i]
+	}
+}
+
+// AppendTime48 appends onto dst LenTime48 bytes with the Unix time encoded
+// as a 48-bit number, which does not overflow for about 8.9 million years.
+// The resulting slice is big endian and suitable for lexicographic sorting.
+func AppendTime48(e *base32.Encoding, t time.Time, dst []byte) []byte {
+	ut := t.Unix()
+	var src [6]byte
+	src[0] = byte(ut >> 40)
+	src[1] = byte(ut >> 32)
+	src[2] = byte(ut >> 24)
+	src[3] = byte(ut >> 16)
+	src[4] = byte(ut >> 8)
+	src[5] = byte(ut)
+	return appendN(e, LenTime48, dst, src[:])
+}
+
+// DecodeTime48 decodes a time encoded by AppendTime48.
+func DecodeTime48(e *base32.Encoding, s string) (time.Time, error) {
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(b) != 6 {
+		return time.Time{}, lenError(len(b), 6)
+	}
+	var ut int64
+	for _, c := range b {
+		ut = ut<<8 | int64(c)
+	}
+	return time.Unix(ut, 0), nil
+}
+
+// AppendFullTime appends onto dst LenFullTime bytes with t as
+// 8 bytes of Unix seconds followed by 4 bytes of nanoseconds, both big endian,
+// which keeps full precision for any time.Time from a Unix time.
+// The results sort in time order for times after 1970.
+// DecodeFullTime reverses it.
+func AppendFullTime(e *base32.Encoding, t time.Time, dst []byte) []byte {
+	var src [12]byte
+	binary.BigEndian.PutUint64(src[:8], uint64(t.Unix()))
+	binary.BigEndian.PutUint32(src[8:], uint32(t.Nanosecond()))
+	return appendN(e, LenFullTime, dst, src[:])
+}
+
+// DecodeFullTime decodes a time encoded by AppendFullTime.
+// It returns ErrOverflow if the nanoseconds are not less than a second.
+func DecodeFullTime(e *base32.Encoding, s string) (time.Time, error) {
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(b) != 12 {
+		return time.Time{}, lenError(len(b), 12)
+	}
+	sec := int64(binary.BigEndian.Uint64(b[:8]))
+	nsec := binary.BigEndian.Uint32(b[8:])
+	if nsec >= 1e9 {
+		return time.Time{}, fmt.Errorf("%w: %d nanoseconds", ErrOverflow, nsec)
+	}
+	return time.Unix(sec, int64(nsec)), nil
+}
+
+// AppendNumber appends onto dst v written as a base 32 number
+// with the fewest symbols, as described by the specification.
+// For example, 32 is "10" and 1234 is "16J". ValueBits reverses it.
+// Unlike AppendUint64, the results only sort in numeric order
+// when they have the same length.
+func AppendNumber(e *base32.Encoding, v uint64, dst []byte) []byte {
+	alphabet := decodeAlphabet(e)
+	var buf [13]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = alphabet[v&31]
+		v >>= 5
+		if v == 0 {
+			break
+		}
+	}
+	return append(dst, buf[i:]...)
+}
+
+// AppendUint64 appends onto dst LenUint64 bytes with v encoded as
+// a big endian 64-bit number. The result sorts lexicographically in numeric order.
+func AppendUint64(e *base32.Encoding, v uint64, dst []byte) []byte {
+	var src [8]byte
+	binary.BigEndian.PutUint64(src[:], v)
+	return appendN(e, LenUint64, dst, src[:])
+}
+
+// DecodeUint64 decodes a number encoded by AppendUint64.
+func DecodeUint64(e *base32.Encoding, s string) (uint64, error) {
+	b, err := decode8(e, s)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// AppendVarint appends onto dst v encoded as an unsigned LEB128 varint,
+// as by binary.PutUvarint, taking from 2 symbols for values below 128
+// up to 16 for the largest. Like AppendUint64LE, the result is NOT
+// lexicographically sortable. DecodeVarint reverses it.
+func AppendVarint(e *base32.Encoding, v uint64, dst []byte) []byte {
+	var src [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(src[:], v)
+	return Append(e, dst, src[:n])
+}
+
+// DecodeVarint decodes a number encoded by AppendVarint.
+// It returns ErrOverflow if the varint does not fit in 64 bits
+// and ErrInvalidLength if it is truncated or followed by extra bytes.
+func DecodeVarint(e *base32.Encoding, s string) (uint64, error) {
+	var buf [16]byte
+	b, err := AppendDecoded(e, buf[:0], []byte(s))
+	if err != nil {
+		return 0, err
+	}
+	v, n := binary.Uvarint(b)
+	switch {
+	case n < 0:
+		return 0, ErrOverflow
+	case n == 0:
+		return 0, fmt.Errorf("%w: truncated varint", ErrInvalidLength)
+	case n != len(b):
+		return 0, fmt.Errorf("%w: %d bytes after varint", ErrInvalidLength, len(b)-n)
+	}
+	return v, nil
+}
+
+// AppendTimeDelta appends onto dst the signed difference in seconds
+// from base to t, zigzag encoded so that 0, -1, 1, -2, 2 become 0, 1, 2, 3, 4,
+// and then written by AppendVarint. Deltas from -64 to 63 seconds take
+// 2 symbols, and up to about four years take 7, against
+// LenTime for an absolute time. DecodeTimeDelta reverses it given the same base.
+func AppendTimeDelta(e *base32.Encoding, base, t time.Time, dst []byte) []byte {
+	d := t.Unix() - base.Unix()
+	return AppendVarint(e, uint64(d<<1)^uint64(d>>63), dst)
+}
+
+// DecodeTimeDelta decodes a time encoded by AppendTimeDelta from base.
+func DecodeTimeDelta(e *base32.Encoding, base time.Time, s string) (time.Time, error) {
+	z, err := DecodeVarint(e, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	d := int64(z>>1) ^ -int64(z&1)
+	return time.Unix(base.Unix()+d, 0), nil
+}
+
+// AppendUint64LE appends onto dst LenUint64 bytes with v encoded as
+// a little endian 64-bit number, for interoperating with little endian formats.
+// Unlike AppendUint64, the result is NOT lexicographically sortable.
+func AppendUint64LE(e *base32.Encoding, v uint64, dst []byte) []byte {
+	var src [8]byte
+	binary.LittleEndian.PutUint64(src[:], v)
+	return appendN(e, LenUint64, dst, src[:])
+}
+
+// DecodeUint64LE decodes a number encoded by AppendUint64LE.
+func DecodeUint64LE(e *base32.Encoding, s string) (uint64, error) {
+	b, err := decode8(e, s)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func decode8(e *base32.Encoding, s string) ([]byte, error) {
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 8 {
+		return nil, lenError(len(b), 8)
+	}
+	return b, nil
+}
+
+// AppendFloat64 appends onto dst LenUint64 bytes with the bit pattern
+// of f encoded as by AppendUint64. It is meant for round trip storage:
+// -0 and each NaN keep their exact bits, but the results do not sort
+// in numeric order.
+func AppendFloat64(e *base32.Encoding, f float64, dst []byte) []byte {
+	return AppendUint64(e, math.Float64bits(f), dst)
+}
+
+// DecodeFloat64 decodes a float encoded by AppendFloat64.
+func DecodeFloat64(e *base32.Encoding, s string) (float64, error) {
+	v, err := DecodeUint64(e, s)
+	return math.Float64frombits(v), err
+}
+
+// AppendDuration appends onto dst LenDuration bytes with d encoded
+// as a big endian 64-bit count of nanoseconds.
+// Negative durations have the sign bit set, so they sort after positive ones.
+func AppendDuration(e *base32.Encoding, d time.Duration, dst []byte) []byte {
+	return AppendUint64(e, uint64(d), dst)
+}
+
+// DecodeDuration decodes a duration encoded by AppendDuration.
+func DecodeDuration(e *base32.Encoding, s string) (time.Duration, error) {
+	v, err := DecodeUint64(e, s)
+	return time.Duration(v), err
+}
+
+// AppendTimeString parses an RFC 3339 timestamp and appends it onto dst
+// as by AppendTime. Time zone offsets are honored, so equal instants
+// encode identically. It returns the parse error for malformed input.
+func AppendTimeString(e *base32.Encoding, rfc3339 string, dst []byte) ([]byte, error) {
+	t, err := time.Parse(time.RFC3339, rfc3339)
+	if err != nil {
+		return dst, err
+	}
+	return AppendTime(e, t, dst), nil
+}
+
 // mod calculates the big endian modulus of the byte string
 func mod(b []byte, m int) (rem int) {
 	for _, c := range b {
@@ -59,19 +478,111 @@ func mod(b []byte, m int) (rem int) {
 }
 
 // Checksum returns the checksum byte for an unencoded body.
+// The checksum of an empty body is '0'.
 func Checksum(body []byte, uppercase bool) byte {
-	alphabet := LowercaseChecksum
-	if uppercase {
-		alphabet = UppercaseChecksum
+	return Alphabet(uppercase, true)[mod(body, 37)]
+}
+
+// ChecksumString returns the checksum byte for an encoded string by treating
+// its symbols as the digits of a big endian base 32 number, as in the spec.
+// The string is normalized first, so hyphens and ambiguous letters are accepted.
+// The case of the result follows e. ChecksumString does not allocate.
+func ChecksumString(e *base32.Encoding, encoded string) (byte, error) {
+	table := symbolTable()
+	rem := 0
+	for i := 0; i < len(encoded); i++ {
+		v := table[encoded[i]]
+		if v == noSymbol {
+			continue
+		}
+		if v >= 32 {
+			return 0, fmt.Errorf("%w %q", ErrInvalidChar, encoded[i])
+		}
+		rem = (rem*32 + int(v)) % 37
+	}
+	return Alphabet(isUpper(e), true)[rem], nil
+}
+
+// ChecksumEncoded returns the check symbol that some other libraries use:
+// the sum of the values of the data symbols of s, mod 37.
+// This differs from the specification, where the check symbol is the value
+// of the whole number mod 37, as from ChecksumString, so it is only for
+// validating IDs from such libraries; it does not detect transposed symbols.
+// It also differs from Checksum, which works on the decoded bytes.
+// s is normalized first, and bytes that are not data symbols are ignored.
+func ChecksumEncoded(s string, upper bool) byte {
+	table := symbolTable()
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		if v := table[s[i]]; v < 32 {
+			sum += int(v)
+		}
+	}
+	return Alphabet(upper, true)[sum%37]
+}
+
+// isUpper reports whether e encodes the value 10 as an uppercase letter
+func isUpper(e *base32.Encoding) bool {
+	// 0x50 -> 01010 000, so the first symbol is 10;
+	// padded encodings write a whole block of 8 symbols
+	var dst [8]byte
+	e.Encode(dst[:], []byte{0x50})
+	return dst[0] >= 'A' && dst[0] <= 'Z'
+}
+
+// SymbolValue returns the value 0 to 31 of the Crockford data symbol c.
+// Both cases are accepted, I and L are read as 1, and O as 0.
+// It returns false for any other byte, including checksum symbols.
+func SymbolValue(c byte) (int, bool) {
+	if v := symbolTable()[c]; v < 32 {
+		return int(v), true
 	}
-	return alphabet[mod(body, 37)]
+	return 0, false
+}
+
+// IsChecksumSymbol reports whether c is one of the checksum-only symbols
+// * ~ $ = U, in either case. Checksum values below 32 use the data symbols,
+// so a trailing data symbol may still be a checksum.
+func IsChecksumSymbol(c byte) bool {
+	v := symbolTable()[c]
+	return v >= 32 && v != noSymbol
+}
+
+// noSymbol marks bytes without a value in the symbol table
+const noSymbol = 0xff
+
+var (
+	symbolTableOnce sync.Once
+	symbolTableData [256]byte
+)
+
+// symbolTable maps each byte to its value as a data symbol (0 to 31),
+// a checksum symbol (32 to 36), or noSymbol.
+// Both cases and the ambiguous letters are included.
+func symbolTable() *[256]byte {
+	symbolTableOnce.Do(func() {
+		for i := range symbolTableData {
+			symbolTableData[i] = noSymbol
+		}
+		for i := 0; i < len(UppercaseChecksum); i++ {
+			symbolTableData[UppercaseChecksum[i]] = byte(i)
+			symbolTableData[LowercaseChecksum[i]] = byte(i)
+		}
+		for _, c := range []byte("IiLl") {
+			symbolTableData[c] = 1
+		}
+		for _, c := range []byte("Oo") {
+			symbolTableData[c] = 0
+		}
+	})
+	return &symbolTableData
 }
 
 func normUpper(c byte) byte {
 	switch c {
 	case '0', 'O', 'o':
 		return '0'
-	case '1', 'I', 'i':
+	case '1', 'I', 'i', 'L', 'l':
 		return '1'
 	case '2', '3', '4', '5', '6', '7', '8', '9', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'J', 'K', 'M', 'N', 'P', 'Q', 'R', 'S', 'T', 'V', 'W', 'X', 'Y', 'Z', '*', '~', '$', '=', 'U':
 		return c
@@ -82,25 +593,224 @@ func normUpper(c byte) byte {
 }
 
 // Normalized returns a normalized version of Crockford encoded bytes of src
-// onto dst and returns the resulting slice. It replaces I with 1, o with 0,
+// onto dst and returns the resulting slice. It replaces I and L with 1, o with 0,
 // and removes invalid characters such as hyphens. The resulting slice is uppercase.
 func Normalized(s string) string {
 	return string(AppendNormalized(nil, []byte(s)))
 }
 
+// NormalizedData is Normalized for plain data strings without a checksum.
+// Normalized keeps U and the other checksum symbols * ~ $ =, because
+// they are valid as the last symbol of a checksummed ID,
+// but none of them are data symbols, so NormalizedData drops them
+// like any other invalid character.
+func NormalizedData(s string) string {
+	b := AppendNormalized(nil, []byte(s))
+	n := 0
+	for _, c := range b {
+		if !IsChecksumSymbol(c) {
+			b[n] = c
+			n++
+		}
+	}
+	return string(b[:n])
+}
+
+// NormalizedDiff returns Normalized(s) and whether normalization
+// altered or dropped any byte of s, such as for logging
+// that an ID was entered in a non-canonical form.
+func NormalizedDiff(s string) (normalized string, changed bool) {
+	if isNormalized([]byte(s)) {
+		return s, false
+	}
+	normalized = Normalized(s)
+	return normalized, true
+}
+
 // AppendNormalized appends a normalized version of Crockford encoded bytes of src
-// onto dst and returns the resulting slice. It replaces I with 1, o with 0,
+// onto dst and returns the resulting slice. It replaces I and L with 1, o with 0,
 // and removes invalid characters such as hyphens. The resulting slice is uppercase.
 func AppendNormalized(dst, src []byte) []byte {
+	// Fast path for input that is already normalized
+	if isNormalized(src) {
+		return append(dst, src...)
+	}
+	dst = grow(dst, len(src))
+	for _, c := range src {
+		if r := normUpper(c); r != 0 {
+			dst = append(dst, r)
+		}
+	}
+	return dst
+}
+
+// canonical marks the bytes that normalization leaves unchanged
+var canonical = func() (t [256]bool) {
+	for _, c := range []byte(UppercaseChecksum) {
+		t[c] = true
+	}
+	return t
+}()
+
+func isNormalized(src []byte) bool {
+	for _, c := range src {
+		if !canonical[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsCanonical reports whether s consists only of uppercase data symbols,
+// so that it is its own normalized form and needs no changes to decode.
+// Unlike normalization, it rejects lowercase letters, the ambiguous letters
+// I, L, and O, separators, and checksum symbols.
+// The empty string is canonical.
+func IsCanonical(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(UppercaseAlphabet, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ToUpper returns s with each lowercase Crockford symbol,
+// including the checksum symbol u, replaced by its uppercase form.
+// Other bytes are left as is, so unlike Normalized,
+// ambiguous letters and hyphens are not changed.
+func ToUpper(s string) string {
+	return mapCase(s, LowercaseChecksum, UppercaseChecksum)
+}
+
+// ToLower returns s with each uppercase Crockford symbol,
+// including the checksum symbol U, replaced by its lowercase form.
+// Other bytes are left as is, so unlike Normalized,
+// ambiguous letters and hyphens are not changed.
+func ToLower(s string) string {
+	return mapCase(s, UppercaseChecksum, LowercaseChecksum)
+}
+
+// DetectCase reports whether the letters in s are uppercase and whether
+// s mixes uppercase and lowercase letters, which often indicates
+// a typo or concatenation bug. Digits, separators, and other bytes
+// are ignored, so a string without letters is reported as uppercase.
+func DetectCase(s string) (isUpper bool, mixed bool) {
+	var upper, lower bool
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		}
+	}
+	return !lower, upper && lower
+}
+
+func mapCase(s, from, to string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if j := strings.IndexByte(from, c); j >= 0 {
+			b[i] = to[j]
+		}
+	}
+	return string(b)
+}
+
+// NormalizedNoChecksum is like Normalized, but treats '=' as padding
+// from a padded base 32 variant and removes it.
+// Normalized keeps '=' because it is also a checksum symbol,
+// so use this only for strings known not to carry a checksum.
+func NormalizedNoChecksum(s string) string {
+	return string(AppendNormalizedNoChecksum(nil, []byte(s)))
+}
+
+// AppendNormalizedNoChecksum is like AppendNormalized, but removes '=' padding.
+// See NormalizedNoChecksum.
+func AppendNormalizedNoChecksum(dst, src []byte) []byte {
 	dst = grow(dst, len(src))
 	for _, c := range src {
+		if r := normUpper(c); r != 0 && r != '=' {
+			dst = append(dst, r)
+		}
+	}
+	return dst
+}
+
+// NormalizeInPlace normalizes b as by AppendNormalized,
+// reusing its storage, and returns the shortened slice.
+// Normalization never grows its input, so this is always safe.
+func NormalizeInPlace(b []byte) []byte {
+	n := 0
+	for _, c := range b {
 		if r := normUpper(c); r != 0 {
+			b[n] = r
+			n++
+		}
+	}
+	return b[:n]
+}
+
+// ContainsExcluded reports whether s contains any of the letters
+// I, L, O, or U in either case. Crockford excludes these from the data symbols,
+// so their presence in user input suggests a typo that normalization
+// would silently change. Note that U is still a valid checksum symbol.
+func ContainsExcluded(s string) bool {
+	return strings.ContainsAny(s, "IiLlOoUu")
+}
+
+// NormalizedFor returns a version of s normalized for alphabet.
+// See AppendNormalizedFor.
+func NormalizedFor(s, alphabet string) string {
+	return string(AppendNormalizedFor(nil, []byte(s), alphabet))
+}
+
+// AppendNormalizedFor appends a version of src normalized for a custom alphabet
+// onto dst and returns the resulting slice. Bytes in alphabet are kept,
+// letters are converted to the case alphabet uses,
+// I and L are replaced with 1 and O with 0 if alphabet lacks them,
+// and all other bytes are removed.
+//
+// Normalized and AppendNormalized always use the standard Crockford alphabet
+// with checksum symbols.
+func AppendNormalizedFor(dst, src []byte, alphabet string) []byte {
+	dst = grow(dst, len(src))
+	for _, c := range src {
+		if r := normFor(c, alphabet); r != 0 {
 			dst = append(dst, r)
 		}
 	}
 	return dst
 }
 
+func normFor(c byte, alphabet string) byte {
+	if strings.IndexByte(alphabet, c) >= 0 {
+		return c
+	}
+	var folded byte
+	switch {
+	case c >= 'a' && c <= 'z':
+		folded = c + 'A' - 'a'
+	case c >= 'A' && c <= 'Z':
+		folded = c + 'a' - 'A'
+	}
+	if folded != 0 && strings.IndexByte(alphabet, folded) >= 0 {
+		return folded
+	}
+	var r byte
+	switch c {
+	case 'I', 'i', 'L', 'l':
+		r = '1'
+	case 'O', 'o':
+		r = '0'
+	}
+	if r != 0 && strings.IndexByte(alphabet, r) >= 0 {
+		return r
+	}
+	return 0
+}
+
 // Random returns LenRandom (8) encoded bytes generated by crypto/rand.
 func Random(e *base32.Encoding) string {
 	return string(AppendRandom(e, nil))
@@ -118,6 +828,148 @@ func AppendRandom(e *base32.Encoding, dst []byte) []byte {
 	return appendN(e, LenRandom, dst, src)
 }
 
+// AppendRandomBits appends onto dst a random token with exactly bits bits
+// of entropy from crypto/rand. It reads bits/8 bytes, rounded up,
+// clears the unused low bits of the last byte, and keeps only the
+// bits/5 symbols, rounded up, that hold random bits.
+// When bits is not a multiple of 5, the low bits of the last symbol are zero.
+// For example, 30 bits gives 6 symbols, where encoding 4 bytes would give 7.
+// The token length may not be one that decodes to whole bytes.
+// It panics if bits < 1.
+func AppendRandomBits(e *base32.Encoding, bits int, dst []byte) []byte {
+	if bits < 1 {
+		panic("invalid bits")
+	}
+	src := make([]byte, (bits+7)/8)
+	if _, err := rand.Read(src); err != nil {
+		panic(err)
+	}
+	if extra := len(src)*8 - bits; extra > 0 {
+		src[len(src)-1] &^= 1<<extra - 1
+	}
+	n := len(dst) + (bits+4)/5
+	return Append(e, dst, src)[:n]
+}
+
+// AppendRandom2 is like AppendRandom, but also returns
+// the sub-slice of full holding only the LenRandom new bytes.
+func AppendRandom2(e *base32.Encoding, dst []byte) (full, added []byte) {
+	n := len(dst)
+	full = AppendRandom(e, dst)
+	return full, full[n:]
+}
+
+// SeededRandom returns LenRandom (8) encoded bytes generated by math/rand
+// from seed, so the same seed always gives the same ID.
+// It is for golden files and deterministic tests only:
+// the IDs are predictable, so never use it in production.
+func SeededRandom(e *base32.Encoding, seed int64) string {
+	var raw [5]byte
+	mathrand.New(mathrand.NewSource(seed)).Read(raw[:])
+	return string(appendN(e, LenRandom, nil, raw[:]))
+}
+
+// RandomWithBytes returns LenRandom (8) encoded bytes generated by crypto/rand
+// along with the 5 raw bytes they encode, such as for showing a token
+// to a user while storing a hash of the raw bytes.
+func RandomWithBytes(e *base32.Encoding) (encoded string, raw []byte) {
+	raw = make([]byte, 5)
+	if _, err := rand.Read(raw); err != nil {
+		panic(err)
+	}
+	return string(appendN(e, LenRandom, nil, raw)), raw
+}
+
+// RandomDualCase returns the uppercase and lowercase encodings
+// of the same 5 bytes from crypto/rand, for storing one form
+// and displaying the other. ToLower(upper) equals lower.
+func RandomDualCase() (upper, lower string) {
+	var raw [5]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		panic(err)
+	}
+	var buf [2 * LenRandom]byte
+	u := appendN(Upper, LenRandom, buf[:0], raw[:])
+	l := appendN(Lower, LenRandom, buf[LenRandom:LenRandom], raw[:])
+	return string(u), string(l)
+}
+
+// RandomLeadingLetter returns chars random symbols from crypto/rand
+// that start with a letter, such as for DOM IDs or variable names.
+// The first symbol is chosen uniformly from the 22 letters of the alphabet
+// of e, A to Z without I, L, O, and U, giving it about 4.46 bits of entropy
+// instead of 5. The others are chosen from all 32 symbols, as by AppendRandomBits.
+// It panics if chars < 1.
+func RandomLeadingLetter(e *base32.Encoding, chars int) string {
+	if chars < 1 {
+		panic("invalid length")
+	}
+	i, err := uniformIndex(rand.Reader, 22)
+	if err != nil {
+		panic(err)
+	}
+	dst := make([]byte, 1, chars)
+	dst[0] = decodeAlphabet(e)[10+i]
+	if chars > 1 {
+		dst = AppendRandomBits(e, 5*(chars-1), dst)
+	}
+	return string(dst)
+}
+
+// VerificationCode returns length uppercase data symbols chosen uniformly
+// from crypto/rand, for short-lived codes sent by SMS or email that
+// users read and type back. The alphabet already leaves out the
+// confusable I, L, O, and U. Each symbol carries 5 bits, so a 6 symbol code
+// has about a billion values: enough with rate limiting and expiry,
+// but too few for long-term IDs. It panics if length < 1.
+func VerificationCode(length int) string {
+	if length < 1 {
+		panic("invalid length")
+	}
+	code := make([]byte, length)
+	for i := range code {
+		n, err := uniformIndex(rand.Reader, 32)
+		if err != nil {
+			panic(err)
+		}
+		code[i] = UppercaseAlphabet[n]
+	}
+	return string(code)
+}
+
+// uniformIndex returns an unbiased random index in [0, n) using bytes from r.
+// Bytes at or above the largest multiple of n are rejected,
+// so each index is equally likely even when n does not divide 256.
+// It panics unless 1 <= n <= 256.
+func uniformIndex(r io.Reader, n int) (int, error) {
+	if n < 1 || n > 256 {
+		panic("invalid range")
+	}
+	limit := 256 - 256%n
+	var b [1]byte
+	for {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+		if int(b[0]) < limit {
+			return int(b[0]) % n, nil
+		}
+	}
+}
+
+// CollisionProbability returns the approximate probability that
+// at least two of count random IDs of randomChars symbols collide.
+// Each symbol carries 5 bits, so there are N = 2**(5*randomChars) possible IDs.
+// It uses the birthday bound approximation 1 - exp(-count*(count-1) / 2N).
+func CollisionProbability(randomChars, count int) float64 {
+	if count < 2 {
+		return 0
+	}
+	n := float64(count)
+	space := math.Exp2(5 * float64(randomChars))
+	return -math.Expm1(-n * (n - 1) / (2 * space))
+}
+
 // MD5 returns encoded bytes generated by MD5 hashing src.
 func MD5(e *base32.Encoding, src []byte) string {
 	return string(AppendMD5(e, nil, src))
@@ -125,13 +977,87 @@ func MD5(e *base32.Encoding, src []byte) string {
 
 // AppendMD5 appends LenMD (26) encoded bytes generated by MD5 hashing src onto dst.
 func AppendMD5(e *base32.Encoding, dst, src []byte) []byte {
-	//16 bytes -> 26 base32 characters
-	var buf [md5.Size]byte
+	return AppendBytes16(e, md5.Sum(src), dst)
+}
+
+// AppendMD5Truncated appends onto dst the encoding of the first n bytes
+// of the MD5 hash of src. It panics unless 1 <= n <= 16.
+// Shorter codes collide sooner: with n bytes, a collision among
+// random inputs becomes likely after about 2**(4*n) of them.
+func AppendMD5Truncated(e *base32.Encoding, dst, src []byte, n int) []byte {
+	if n < 1 || n > md5.Size {
+		panic("invalid truncation length")
+	}
+	sum := md5.Sum(src)
+	return Append(e, dst, sum[:n])
+}
+
+// Algorithm tags for AppendMD5Prefixed and DecodeDigestPrefixed
+const (
+	TagMD5    byte = 1 // a 16 byte MD5 digest
+	TagSHA256 byte = 2 // a 32 byte SHA-256 digest
+)
+
+// AppendMD5Prefixed appends onto dst the encoding of tag followed by
+// the MD5 hash of src, so that digests of different algorithms kept
+// in one store can be told apart. tag is normally TagMD5.
+// DecodeDigestPrefixed reverses it.
+func AppendMD5Prefixed(e *base32.Encoding, tag byte, dst, src []byte) []byte {
+	var b [1 + md5.Size]byte
+	b[0] = tag
+	sum := md5.Sum(src)
+	copy(b[1:], sum[:])
+	return Append(e, dst, b[:])
+}
+
+// DecodeDigestPrefixed decodes s into its algorithm tag and digest.
+// It returns ErrInvalidLength if the digest has the wrong length
+// for TagMD5 or TagSHA256. Digests with other tags are returned as is.
+func DecodeDigestPrefixed(e *base32.Encoding, s string) (tag byte, digest []byte, err error) {
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(b) < 1 {
+		return 0, nil, fmt.Errorf("%w: missing tag", ErrInvalidLength)
+	}
+	tag, digest = b[0], b[1:]
+	want := -1
+	switch tag {
+	case TagMD5:
+		want = md5.Size
+	case TagSHA256:
+		want = sha256.Size
+	}
+	if want != -1 && len(digest) != want {
+		return 0, nil, lenError(len(digest), want)
+	}
+	return tag, digest, nil
+}
+
+// DeterministicID returns a LenBytes16 (26) symbol key derived from fields,
+// such as for idempotency keys built from request fields.
+// Each field is written to a SHA-256 hash preceded by its length
+// as a big endian uint64, so ("ab", "c") and ("a", "bc") differ,
+// and the first 16 bytes of the digest are encoded.
+func DeterministicID(e *base32.Encoding, fields ...[]byte) string {
+	h := sha256.New()
+	var n [8]byte
+	for _, f := range fields {
+		binary.BigEndian.PutUint64(n[:], uint64(len(f)))
+		h.Write(n[:])
+		h.Write(f)
+	}
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	return string(AppendBytes16(e, b, nil))
+}
 
-	h := md5.New()
-	h.Write(src)
-	h.Sum(buf[:0])
-	return appendN(e, LenMD5, dst, buf[:])
+// AppendBytes16 appends LenBytes16 (26) encoded bytes of b onto dst.
+// It is useful for UUIDs, digests, and other 16 byte values.
+func AppendBytes16(e *base32.Encoding, b [16]byte, dst []byte) []byte {
+	//16 bytes -> 26 base32 characters
+	return appendN(e, LenBytes16, dst, b[:])
 }
 
 // Append returns a slice with the encoded version of src appended onto dst.
@@ -142,6 +1068,56 @@ func Append(e *base32.Encoding, dst, src []byte) []byte {
 	return appendN(e, n, dst, src)
 }
 
+// CompareSize returns the length of rawBytes bytes encoded without padding
+// by this package, as hex, and as padded standard base64.
+// For example, the 16 bytes of a UUID take 26 symbols here, 32 as hex
+// (36 in the hyphenated UUID form), and 24 in base64.
+func CompareSize(rawBytes int) (crockfordChars, hexChars, base64Chars int) {
+	return Upper.EncodedLen(rawBytes), hex.EncodedLen(rawBytes), base64.StdEncoding.EncodedLen(rawBytes)
+}
+
+// EncodeAll returns the encodings of srcs as a parallel slice.
+// Encoding never fails, so there is no error. All the strings share
+// one buffer, which saves an allocation per string for many small inputs,
+// but also means that keeping any one of them keeps the buffer alive.
+func EncodeAll(e *base32.Encoding, srcs [][]byte) []string {
+	n := 0
+	for _, src := range srcs {
+		n += e.EncodedLen(len(src))
+	}
+	buf := make([]byte, 0, n)
+	for _, src := range srcs {
+		buf = Append(e, buf, src)
+	}
+	all := string(buf)
+	out := make([]string, len(srcs))
+	off := 0
+	for i, src := range srcs {
+		end := off + e.EncodedLen(len(src))
+		out[i] = all[off:end]
+		off = end
+	}
+	return out
+}
+
+// AppendChecked is like Append, but then decodes the encoded output and
+// returns an error if it does not match src, leaving dst unchanged.
+// It guards against encoder bugs and memory corruption when minting
+// critical IDs, at the cost of a decode and an allocation per call.
+func AppendChecked(e *base32.Encoding, dst, src []byte) ([]byte, error) {
+	n := len(dst)
+	dst = Append(e, dst, src)
+	decoded := make([]byte, e.DecodedLen(len(dst)-n))
+	m, err := e.Decode(decoded, dst[n:])
+	if err != nil {
+		return dst[:n], fmt.Errorf("crockford: encoding does not round trip: %w", err)
+	}
+	if string(decoded[:m]) != string(src) {
+		return dst[:n], fmt.Errorf("crockford: encoding does not round trip")
+	}
+	return dst, nil
+}
+
 func appendN(e *base32.Encoding, n int, dst, src []byte) []byte {
 	dst = grow(dst, n)
 	tar := dst[len(dst) : len(dst)+n]
@@ -162,6 +1138,26 @@ func Partition(s string, gap int) string {
 	return string(AppendPartition(nil, []byte(s), gap))
 }
 
+// Chunk splits s into pieces of size bytes, with the last piece
+// holding the remainder, such as for showing a long hash across lines.
+// Encoded strings can be split anywhere, since joining the pieces
+// back together gives the same string. Like Partition, it is not Unicode aware.
+// It panics if size < 1.
+func Chunk(s string, size int) []string {
+	if size < 1 {
+		panic("invalid size")
+	}
+	chunks := make([]string, 0, (len(s)+size-1)/size)
+	for len(s) > size {
+		chunks = append(chunks, s[:size])
+		s = s[size:]
+	}
+	if s != "" {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
+
 // AppendPartition appends onto dst the result of
 // partitioning src with hyphens ("-") every gap bytes.
 func AppendPartition(dst, src []byte, gap int) []byte {
@@ -205,6 +1201,41 @@ func AppendPartition(dst, src []byte, gap int) []byte {
 	}
 }
 
+// AppendGroupedEncode appends onto dst the encoding of src with sep
+// inserted every group symbols, in a single pass. It gives the same result
+// as encoding and then calling AppendPartition, but with any separator.
+// The separators are not part of the canonical form
+// and must be removed before decoding, as Normalized does for hyphens.
+func AppendGroupedEncode(e *base32.Encoding, dst, src []byte, group int, sep byte) []byte {
+	if group < 1 {
+		panic("invalid group")
+	}
+	n := e.EncodedLen(len(src))
+	if n == 0 {
+		return dst
+	}
+	gaps := (n - 1) / group
+	total := n + gaps
+	dst = grow(dst, total)
+	out := dst[len(dst) : len(dst)+total]
+	// encode into the tail, then move the groups forward
+	e.Encode(out[gaps:], src)
+	for w, r := 0, gaps; w < total; {
+		k := group
+		if k > total-w {
+			k = total - w
+		}
+		copy(out[w:w+k], out[r:r+k])
+		w += k
+		r += k
+		if w < total {
+			out[w] = sep
+			w++
+		}
+	}
+	return dst[:len(dst)+total]
+}
+
 func splitLast(b []byte, n int) ([]byte, []byte) {
 	return b[:len(b)-n], b[len(b)-n:]
 }
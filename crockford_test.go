@@ -1,9 +1,18 @@
 package crockford_test
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +20,78 @@ import (
 	"github.com/carlmjohnson/crockford"
 )
 
+func TestNewBuffer(t *testing.T) {
+	be.Equal(t, 0, cap(crockford.NewBuffer()))
+	buf := crockford.NewBuffer(crockford.LenTime, crockford.LenRandom, crockford.LenChecksum)
+	be.Equal(t, 0, len(buf))
+	be.Equal(t, 17, cap(buf))
+
+	now := time.Now()
+	var id []byte
+	allocs := testing.AllocsPerRun(100, func() {
+		id = crockford.AppendTime(crockford.Upper, now, buf[:0])
+		id = crockford.AppendRandom(crockford.Upper, id)
+		ck, _ := crockford.ChecksumString(crockford.Upper, string(id))
+		id = append(id, ck)
+	})
+	be.Zero(t, allocs)
+	be.Equal(t, 17, len(id))
+	be.Equal(t, &buf[:1][0], &id[0])
+}
+
+func TestNormalizedData(t *testing.T) {
+	for _, tc := range []struct {
+		in, checksummed, data string
+	}{
+		{"", "", ""},
+		{"D1JP-RV3F", "D1JPRV3F", "D1JPRV3F"},
+		{"d1jpu", "D1JPU", "D1JP"},
+		{"UuU0", "UUU0", "0"},
+		{"ab*~$=", "AB*~$=", "AB"},
+		{"oil", "011", "011"},
+	} {
+		be.Equal(t, tc.checksummed, crockford.Normalized(tc.in))
+		be.Equal(t, tc.data, crockford.NormalizedData(tc.in))
+	}
+}
+
+func TestNormalizedDiff(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+		changed  bool
+	}{
+		{"", "", false},
+		{"D1JPRV3F41VPYWKCCG", "D1JPRV3F41VPYWKCCG", false},
+		{"0123U", "0123U", false},
+		{"d1jp-rv3f", "D1JPRV3F", true},
+		{"OIL", "011", true},
+		{"ABC ", "ABC", true},
+	} {
+		got, changed := crockford.NormalizedDiff(tc.in)
+		be.Equal(t, tc.want, got)
+		be.Equal(t, tc.changed, changed)
+	}
+}
+
+func TestIsCanonical(t *testing.T) {
+	for _, in := range []string{"", "0", "D1JPRV3F41VPYWKCCG", crockford.UppercaseAlphabet} {
+		be.True(t, crockford.IsCanonical(in))
+		be.Equal(t, in, crockford.Normalized(in))
+	}
+	for _, in := range []string{
+		"d1jprv3f41vpywkccg", "D1JP-RV3F", "OI", "L0", "0U", "12*", "~", "$", "=", " 1", "1\n", "\xff",
+	} {
+		be.False(t, crockford.IsCanonical(in))
+	}
+}
+
+func TestAlphabet(t *testing.T) {
+	be.Equal(t, crockford.UppercaseAlphabet, crockford.Alphabet(true, false))
+	be.Equal(t, crockford.UppercaseChecksum, crockford.Alphabet(true, true))
+	be.Equal(t, crockford.LowercaseAlphabet, crockford.Alphabet(false, false))
+	be.Equal(t, crockford.LowercaseChecksum, crockford.Alphabet(false, true))
+}
+
 func TestMD5(t *testing.T) {
 	cases := map[string]struct {
 		in   string
@@ -104,6 +185,91 @@ func TestTime(t *testing.T) {
 	}
 }
 
+func TestTimeAllocs(t *testing.T) {
+	now := time.Now()
+	dst := make([]byte, 0, crockford.LenTime)
+	for _, e := range []*base32.Encoding{crockford.Upper, crockford.Lower} {
+		allocs := testing.AllocsPerRun(100, func() {
+			dst = crockford.AppendTime(e, now, dst[:0])
+		})
+		be.Zero(t, allocs)
+		allocs = testing.AllocsPerRun(100, func() {
+			_ = crockford.Time(e, now)
+		})
+		be.True(t, allocs <= 1)
+	}
+}
+
+func BenchmarkTime(b *testing.B) {
+	now := time.Now()
+	dst := make([]byte, 0, crockford.LenTime)
+	b.Run("AppendTime", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			dst = crockford.AppendTime(crockford.Upper, now, dst[:0])
+		}
+	})
+	b.Run("Time", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = crockford.Time(crockford.Upper, now)
+		}
+	})
+}
+
+func TestMaxTime(t *testing.T) {
+	max := crockford.MaxTime(crockford.TimeSeconds40)
+	be.Equal(t, 36812, max.UTC().Year())
+	s := crockford.AppendTimeRandom(crockford.Upper, max, 0, nil)
+	be.Equal(t, "ZZZZZZZZ", string(s))
+	got, _, err := crockford.SplitTimeRandom(crockford.Upper, string(s), 0)
+	be.NilErr(t, err)
+	be.True(t, max.Equal(got))
+	over := crockford.AppendTimeRandom(crockford.Upper, max.Add(time.Second), 0, nil)
+	got, _, err = crockford.SplitTimeRandom(crockford.Upper, string(over), 0)
+	be.NilErr(t, err)
+	be.False(t, max.Add(time.Second).Equal(got))
+
+	max = crockford.MaxTime(crockford.TimeSeconds48)
+	got, err = crockford.DecodeTime48(crockford.Upper, string(crockford.AppendTime48(crockford.Upper, max, nil)))
+	be.NilErr(t, err)
+	be.True(t, max.Equal(got))
+	got, err = crockford.DecodeTime48(crockford.Upper, string(crockford.AppendTime48(crockford.Upper, max.Add(time.Second), nil)))
+	be.NilErr(t, err)
+	be.False(t, max.Add(time.Second).Equal(got))
+
+	max = crockford.MaxTime(crockford.TimeMillis48)
+	be.Equal(t, 10889, max.UTC().Year())
+	var seq crockford.Sequencer
+	b, err := crockford.DecodeString(crockford.Upper, string(seq.AppendID(crockford.Upper, max, nil)))
+	be.NilErr(t, err)
+	be.Equal(t, "\xff\xff\xff\xff\xff\xff", string(b[:6]))
+
+	defer func() { be.Nonzero(t, recover()) }()
+	crockford.MaxTime(crockford.TimeUnit(-1))
+}
+
+func TestTimePrefix(t *testing.T) {
+	base := time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC)
+	for _, bucket := range []time.Duration{time.Second, time.Minute, time.Hour, 24 * time.Hour, 32 * time.Second} {
+		start := base.Truncate(bucket)
+		prefix := crockford.TimePrefix(crockford.Upper, start.Add(bucket/3), bucket)
+		be.Equal(t, prefix, crockford.TimePrefix(crockford.Upper, start.Add(bucket-1), bucket))
+		be.True(t, len(prefix) <= crockford.LenTime)
+		for _, when := range []time.Time{start, start.Add(bucket / 2), start.Add(bucket - time.Second)} {
+			be.True(t, strings.HasPrefix(crockford.Time(crockford.Upper, when), prefix))
+		}
+	}
+	be.Equal(t, crockford.Time(crockford.Lower, base), crockford.TimePrefix(crockford.Lower, base, time.Second))
+	// buckets of 32 seconds share all but the last symbol
+	aligned := time.Unix(32*49308862, 0)
+	be.Equal(t, crockford.Time(crockford.Upper, aligned)[:crockford.LenTime-1],
+		crockford.TimePrefix(crockford.Upper, aligned.Add(31*time.Second), 32*time.Second))
+	be.Unequal(t,
+		crockford.TimePrefix(crockford.Upper, base, time.Minute),
+		crockford.TimePrefix(crockford.Upper, base.Add(time.Minute), time.Minute))
+}
+
 func TestAppendTime(t *testing.T) {
 	cases := map[string]struct {
 		want string
@@ -161,6 +327,56 @@ func TestAppend(t *testing.T) {
 	}
 }
 
+func TestCompareSize(t *testing.T) {
+	c, h, b64 := crockford.CompareSize(16)
+	be.Equal(t, crockford.LenUUID, c)
+	be.Equal(t, 32, h)
+	be.Equal(t, 24, b64)
+
+	c, h, b64 = crockford.CompareSize(0)
+	be.Equal(t, 0, c+h+b64)
+	c, h, b64 = crockford.CompareSize(5)
+	be.Equal(t, crockford.LenRandom, c)
+	be.Equal(t, 10, h)
+	be.Equal(t, 8, b64)
+}
+
+func TestEncodeAll(t *testing.T) {
+	srcs := [][]byte{[]byte("hello world"), nil, []byte("a"), {0xff, 0xff, 0xff, 0xff, 0xff}}
+	got := crockford.EncodeAll(crockford.Lower, srcs)
+	be.Equal(t, len(srcs), len(got))
+	for i, src := range srcs {
+		be.Equal(t, crockford.Lower.EncodeToString(src), got[i])
+	}
+	be.Equal(t, 0, len(crockford.EncodeAll(crockford.Upper, nil)))
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = crockford.EncodeAll(crockford.Lower, srcs)
+	})
+	be.True(t, allocs <= 3)
+}
+
+func BenchmarkEncodeAll(b *testing.B) {
+	srcs := make([][]byte, 1000)
+	for i := range srcs {
+		srcs[i] = []byte(fmt.Sprint(i * 7919))
+	}
+	b.Run("EncodeAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = crockford.EncodeAll(crockford.Upper, srcs)
+		}
+	})
+	b.Run("EncodeToString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			out := make([]string, len(srcs))
+			for j, src := range srcs {
+				out[j] = crockford.Upper.EncodeToString(src)
+			}
+		}
+	})
+}
+
 func ExamplePartition() {
 	t := time.Date(1969, 7, 24, 16, 50, 35, 0, time.UTC)
 	s := crockford.Time(crockford.Lower, t)
@@ -169,6 +385,43 @@ func ExamplePartition() {
 	// zzzj-satv
 }
 
+// hexCodec is a fake crockford.Codec
+type hexCodec struct{}
+
+func (hexCodec) EncodeToString(src []byte) string { return hex.EncodeToString(src) }
+
+func (hexCodec) DecodeString(s string) ([]byte, error) { return hex.DecodeString(s) }
+
+func ExampleCodec() {
+	// keyFor takes a Codec, so it can be tested with a fake
+	keyFor := func(enc crockford.Codec, id []byte) string {
+		return "user:" + enc.EncodeToString(id)
+	}
+	id := []byte("hello")
+	fmt.Println(keyFor(crockford.Upper, id))
+	fmt.Println(keyFor(crockford.UpperCk, id))
+	fmt.Println(keyFor(hexCodec{}, id))
+	// Output:
+	// user:D1JPRV3F
+	// user:D1JPRV3FJ
+	// user:68656c6c6f
+}
+
+func TestChunk(t *testing.T) {
+	s := crockford.MD5(crockford.Lower, []byte("Hello, World!"))
+	chunks := crockford.Chunk(s, 8)
+	be.AllEqual(t, []string{s[:8], s[8:16], s[16:24], s[24:]}, chunks)
+	be.Equal(t, 2, len(chunks[3]))
+	be.Equal(t, s, strings.Join(chunks, ""))
+	be.AllEqual(t, []string{s}, crockford.Chunk(s, len(s)))
+	be.AllEqual(t, []string{s}, crockford.Chunk(s, 100))
+	be.Equal(t, 26, len(crockford.Chunk(s, 1)))
+	be.Equal(t, 0, len(crockford.Chunk("", 4)))
+
+	defer func() { be.Nonzero(t, recover()) }()
+	crockford.Chunk(s, 0)
+}
+
 func TestPartition(t *testing.T) {
 	for _, tc := range []struct {
 		gap     int
@@ -239,3 +492,921 @@ func chunk(s string, size int) []string {
 	}
 	return append(res, s[(n-1)*size:])
 }
+
+func TestChecksumString(t *testing.T) {
+	for _, tc := range []struct {
+		e    *base32.Encoding
+		in   string
+		want byte
+	}{
+		{crockford.Upper, "", '0'},
+		{crockford.Upper, "0", '0'},
+		{crockford.Upper, "10", '*'},
+		{crockford.Upper, "16J", 'D'},
+		{crockford.Lower, "16j", 'd'},
+		{crockford.Upper, "1-6-j", 'D'},
+		{crockford.Upper, "I6J", 'D'},
+		{crockford.Upper, "14", 'U'},
+	} {
+		got, err := crockford.ChecksumString(tc.e, tc.in)
+		be.NilErr(t, err)
+		be.Equal(t, tc.want, got)
+	}
+	// Checksum symbols are not data
+	_, err := crockford.ChecksumString(crockford.Upper, "10*")
+	be.Nonzero(t, err)
+
+	// Whole bytes agree with the byte checksum
+	for _, in := range []string{"\x00\x00\x00\x00\x00", "\xff\xff\xff\xff\xff", "hello", "hello worl"} {
+		s := crockford.Upper.EncodeToString([]byte(in))
+		got, err := crockford.ChecksumString(crockford.Upper, s)
+		be.NilErr(t, err)
+		be.Equal(t, crockford.Checksum([]byte(in), true), got)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = crockford.ChecksumString(crockford.Lower, "d1jprv3f41vpywkc")
+	})
+	be.Zero(t, allocs)
+}
+
+func BenchmarkChecksumString(b *testing.B) {
+	const s = "D1JPRV3F41VPYWKC"
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = crockford.ChecksumString(crockford.Upper, s)
+		}
+	})
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			body, _ := crockford.Upper.DecodeString(crockford.Normalized(s))
+			_ = crockford.Checksum(body, true)
+		}
+	})
+}
+
+func TestEmpty(t *testing.T) {
+	be.Equal(t, '0', crockford.Checksum(nil, true))
+	be.Equal(t, '0', crockford.Checksum([]byte{}, false))
+	c, err := crockford.ChecksumString(crockford.Upper, "")
+	be.NilErr(t, err)
+	be.Equal(t, '0', c)
+	c, err = crockford.ChecksumString(crockford.Upper, "--")
+	be.NilErr(t, err)
+	be.Equal(t, '0', c)
+
+	be.Equal(t, "", crockford.Normalized(""))
+	be.Equal(t, "", crockford.Normalized("---"))
+	be.Equal(t, "", crockford.Partition("", 4))
+	be.Equal(t, crockford.LenMD5, len(crockford.MD5(crockford.Upper, nil)))
+
+	dst := []byte("abc")
+	be.Equal(t, "abc", string(crockford.Append(crockford.Upper, dst, nil)))
+	be.Equal(t, "abc", string(crockford.AppendNormalized(dst, nil)))
+	be.Equal(t, "abc", string(crockford.AppendPartition(dst, nil, 2)))
+}
+
+func TestCase(t *testing.T) {
+	for _, tc := range []struct{ in, upper, lower string }{
+		{"", "", ""},
+		{"0123456789abcdefghjkmnpqrstvwxyz", crockford.UppercaseAlphabet, crockford.LowercaseAlphabet},
+		{"01f0qr80", "01F0QR80", "01f0qr80"},
+		{"1234-abcd*u", "1234-ABCD*U", "1234-abcd*u"},
+		{"IiLlOo", "IiLlOo", "IiLlOo"},
+	} {
+		be.Equal(t, tc.upper, crockford.ToUpper(tc.in))
+		be.Equal(t, tc.lower, crockford.ToLower(tc.in))
+		be.Equal(t, crockford.ToUpper(tc.in), crockford.ToUpper(crockford.ToLower(tc.in)))
+	}
+	for _, in := range []string{crockford.UppercaseChecksum, crockford.LowercaseChecksum, "01F0QR80"} {
+		be.Equal(t, crockford.ToUpper(in), crockford.ToUpper(crockford.ToLower(in)))
+		be.Equal(t, crockford.ToLower(in), crockford.ToLower(crockford.ToUpper(in)))
+	}
+}
+
+func TestCollisionProbability(t *testing.T) {
+	near := func(want, got float64) {
+		t.Helper()
+		if math.Abs(want-got) > want*1e-6 {
+			t.Fatalf("want %g; got %g", want, got)
+		}
+	}
+	be.Equal(t, 0, crockford.CollisionProbability(crockford.LenRandom, 0))
+	be.Equal(t, 0, crockford.CollisionProbability(crockford.LenRandom, 1))
+	near(math.Exp2(-40), crockford.CollisionProbability(crockford.LenRandom, 2))
+	near(1-math.Exp(-0.5*(1-math.Exp2(-20))), crockford.CollisionProbability(crockford.LenRandom, 1<<20))
+	be.Equal(t, 1, crockford.CollisionProbability(1, 1000))
+	be.True(t, crockford.CollisionProbability(16, 1<<20) < crockford.CollisionProbability(8, 1<<20))
+}
+
+func TestAppendBytes16(t *testing.T) {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(i * 17)
+	}
+	dst := crockford.AppendBytes16(crockford.Upper, b, []byte("id:"))
+	be.Equal(t, "id:", string(dst[:3]))
+	be.Equal(t, crockford.LenBytes16, len(dst)-3)
+	be.Equal(t, crockford.Upper.EncodeToString(b[:]), string(dst[3:]))
+
+	got, err := crockford.DecodeString(crockford.Upper, string(dst[3:]))
+	be.NilErr(t, err)
+	be.Equal(t, string(b[:]), string(got))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = crockford.AppendBytes16(crockford.Upper, b, dst[:0])
+	})
+	be.Zero(t, allocs)
+}
+
+func TestAppendRandomBits(t *testing.T) {
+	for _, tc := range []struct{ bits, n int }{
+		{1, 1}, {5, 1}, {6, 2}, {8, 2}, {30, 6}, {32, 7}, {40, 8}, {128, 26},
+	} {
+		for i := 0; i < 50; i++ {
+			s := crockford.AppendRandomBits(crockford.Upper, tc.bits, []byte("x"))
+			be.Equal(t, "x", string(s[:1]))
+			be.Equal(t, tc.n, len(s)-1)
+			v, ok := crockford.SymbolValue(s[len(s)-1])
+			be.True(t, ok)
+			// unused low bits of the last symbol are clear
+			unused := tc.n*5 - tc.bits
+			be.Equal(t, 0, v&(1<<unused-1))
+		}
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		seen[string(crockford.AppendRandomBits(crockford.Lower, 1, nil))] = true
+	}
+	be.Equal(t, 2, len(seen))
+	be.True(t, seen["0"] && seen["g"])
+}
+
+func TestAppendMD5Prefixed(t *testing.T) {
+	src := []byte("Hello, World!")
+	s := crockford.AppendMD5Prefixed(crockford.Upper, crockford.TagMD5, []byte("x"), src)
+	be.Equal(t, byte('x'), s[0])
+	tag, digest, err := crockford.DecodeDigestPrefixed(crockford.Lower, strings.ToLower(string(s[1:])))
+	be.NilErr(t, err)
+	be.Equal(t, crockford.TagMD5, tag)
+	sum := md5.Sum(src)
+	be.Equal(t, string(sum[:]), string(digest))
+
+	sha := sha256.Sum256(src)
+	tagged := crockford.Upper.EncodeToString(append([]byte{crockford.TagSHA256}, sha[:]...))
+	tag, digest, err = crockford.DecodeDigestPrefixed(crockford.Upper, tagged)
+	be.NilErr(t, err)
+	be.Equal(t, crockford.TagSHA256, tag)
+	be.Equal(t, string(sha[:]), string(digest))
+
+	// the tag disambiguates digests of the same length
+	other := crockford.AppendMD5Prefixed(crockford.Upper, 99, nil, src)
+	be.Unequal(t, string(s[1:]), string(other))
+	tag, _, err = crockford.DecodeDigestPrefixed(crockford.Upper, string(other))
+	be.NilErr(t, err)
+	be.Equal(t, byte(99), tag)
+
+	// MD5 sized digest with the SHA-256 tag
+	wrong := crockford.Upper.EncodeToString(append([]byte{crockford.TagSHA256}, sum[:]...))
+	_, _, err = crockford.DecodeDigestPrefixed(crockford.Upper, wrong)
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+	_, _, err = crockford.DecodeDigestPrefixed(crockford.Upper, "")
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+}
+
+func TestAppendMD5Truncated(t *testing.T) {
+	in := []byte("Hello, World!")
+	full := crockford.MD5(crockford.Lower, in)
+	be.Equal(t, full, string(crockford.AppendMD5Truncated(crockford.Lower, nil, in, 16)))
+	be.Equal(t, full[:8], string(crockford.AppendMD5Truncated(crockford.Lower, nil, in, 5)))
+	be.Equal(t, full[:16], string(crockford.AppendMD5Truncated(crockford.Lower, nil, in, 10)))
+	be.Equal(t, 2, len(crockford.AppendMD5Truncated(crockford.Lower, nil, in, 1)))
+
+	dst := crockford.AppendMD5Truncated(crockford.Lower, []byte("x"), in, 5)
+	be.Equal(t, "x"+full[:8], string(dst))
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = crockford.AppendMD5Truncated(crockford.Lower, dst[:0], in, 5)
+	})
+	be.Zero(t, allocs)
+
+	for _, n := range []int{0, 17} {
+		func() {
+			defer func() { be.Nonzero(t, recover()) }()
+			crockford.AppendMD5Truncated(crockford.Lower, nil, in, n)
+		}()
+	}
+}
+
+func TestAppendDuration(t *testing.T) {
+	for _, d := range []time.Duration{0, 1, time.Second, 90 * time.Minute, -1, -time.Hour, math.MaxInt64, math.MinInt64} {
+		b := crockford.AppendDuration(crockford.Upper, d, nil)
+		be.Equal(t, crockford.LenDuration, len(b))
+		got, err := crockford.DecodeDuration(crockford.Upper, string(b))
+		be.NilErr(t, err)
+		be.Equal(t, d, got)
+		got, err = crockford.DecodeDuration(crockford.Lower, crockford.Partition(string(b), 4))
+		be.NilErr(t, err)
+		be.Equal(t, d, got)
+	}
+	be.True(t, string(crockford.AppendDuration(crockford.Upper, time.Second, nil)) <
+		string(crockford.AppendDuration(crockford.Upper, time.Hour, nil)))
+
+	_, err := crockford.DecodeDuration(crockford.Upper, "00000000")
+	be.Nonzero(t, err)
+
+	dst := crockford.AppendDuration(crockford.Upper, time.Hour, nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = crockford.AppendDuration(crockford.Upper, time.Hour, dst[:0])
+	})
+	be.Zero(t, allocs)
+}
+
+func TestAppendVarint(t *testing.T) {
+	for _, tc := range []struct {
+		v uint64
+		n int
+	}{
+		{0, 2}, {1, 2}, {127, 2}, {128, 4}, {300, 4}, {1 << 32, 8},
+		{math.MaxUint64 - 1, 16}, {math.MaxUint64, 16},
+	} {
+		s := crockford.AppendVarint(crockford.Upper, tc.v, []byte("x"))
+		be.Equal(t, tc.n, len(s)-1)
+		got, err := crockford.DecodeVarint(crockford.Lower, strings.ToLower(string(s[1:])))
+		be.NilErr(t, err)
+		be.Equal(t, tc.v, got)
+	}
+	for _, tc := range []struct {
+		in  []byte
+		err error
+	}{
+		{nil, crockford.ErrInvalidLength},
+		{[]byte{0x80}, crockford.ErrInvalidLength},
+		{[]byte{1, 2}, crockford.ErrInvalidLength},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, crockford.ErrOverflow},
+	} {
+		_, err := crockford.DecodeVarint(crockford.Upper, crockford.Upper.EncodeToString(tc.in))
+		be.True(t, errors.Is(err, tc.err))
+	}
+}
+
+func TestAppendTimeDelta(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		d time.Duration
+		n int
+	}{
+		{0, 2},
+		{time.Second, 2},
+		{-time.Second, 2},
+		{63 * time.Second, 2},
+		{-64 * time.Second, 2},
+		{64 * time.Second, 4},
+		{time.Hour, 4},
+		{-24 * time.Hour, 5},
+		{365 * 24 * time.Hour, 7},
+		{-100 * 365 * 24 * time.Hour, 8},
+	} {
+		when := base.Add(tc.d)
+		s := crockford.AppendTimeDelta(crockford.Upper, base, when, nil)
+		be.Equal(t, tc.n, len(s))
+		got, err := crockford.DecodeTimeDelta(crockford.Upper, base, string(s))
+		be.NilErr(t, err)
+		be.True(t, when.Equal(got))
+	}
+	be.Equal(t, "08", string(crockford.AppendTimeDelta(crockford.Upper, base, base.Add(time.Second), nil)))
+	be.Equal(t, "04", string(crockford.AppendTimeDelta(crockford.Upper, base, base.Add(-time.Second), nil)))
+
+	_, err := crockford.DecodeTimeDelta(crockford.Upper, base, "")
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+}
+
+func TestAppendUint64(t *testing.T) {
+	prev := ""
+	for _, v := range []uint64{0, 1, 31, 32, math.MaxUint32, 1 << 40, math.MaxUint64 - 1, math.MaxUint64} {
+		enc := crockford.AppendUint64(crockford.Upper, v, nil)
+		encLE := crockford.AppendUint64LE(crockford.Upper, v, nil)
+		be.Equal(t, crockford.LenUint64, len(enc))
+		be.Equal(t, crockford.LenUint64, len(encLE))
+		be.True(t, prev < string(enc))
+		prev = string(enc)
+
+		got, err := crockford.DecodeUint64(crockford.Upper, string(enc))
+		be.NilErr(t, err)
+		be.Equal(t, v, got)
+		got, err = crockford.DecodeUint64LE(crockford.Lower, string(encLE))
+		be.NilErr(t, err)
+		be.Equal(t, v, got)
+	}
+	// little endian is not sortable
+	be.True(t, string(crockford.AppendUint64LE(crockford.Upper, 256, nil)) <
+		string(crockford.AppendUint64LE(crockford.Upper, 1, nil)))
+
+	_, err := crockford.DecodeUint64LE(crockford.Upper, "0000000000000000")
+	be.Nonzero(t, err)
+}
+
+func TestAppendTimeDescending(t *testing.T) {
+	var prev string
+	for i, when := range []time.Time{
+		time.Unix(0, 0),
+		time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		crockford.MaxTime(crockford.TimeSeconds40),
+	} {
+		s := string(crockford.AppendTimeDescending(crockford.Upper, when, nil))
+		be.Equal(t, crockford.LenTime, len(s))
+		if i > 0 {
+			be.True(t, s < prev)
+		}
+		prev = s
+		got, err := crockford.DecodeTimeDescending(crockford.Lower, strings.ToLower(s))
+		be.NilErr(t, err)
+		be.True(t, when.Equal(got))
+	}
+	be.Equal(t, "ZZZZZZZZ", string(crockford.AppendTimeDescending(crockford.Upper, time.Unix(0, 0), nil)))
+	_, err := crockford.DecodeTimeDescending(crockford.Upper, "ZZZZZZZZZZ")
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+}
+
+func TestAppendFullTime(t *testing.T) {
+	for _, when := range []time.Time{
+		time.Unix(0, 0),
+		time.Unix(1_600_000_000, 123_456_789),
+		time.Unix(1_600_000_000, 999_999_999),
+		time.Date(2262, 4, 12, 0, 0, 0, 1, time.UTC), // beyond UnixNano
+		time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(9999, 12, 31, 23, 59, 59, 999_999_999, time.UTC),
+	} {
+		s := crockford.AppendFullTime(crockford.Upper, when, []byte("x"))
+		be.Equal(t, 1+crockford.LenFullTime, len(s))
+		got, err := crockford.DecodeFullTime(crockford.Lower, strings.ToLower(string(s[1:])))
+		be.NilErr(t, err)
+		be.True(t, when.Equal(got))
+		be.Equal(t, when.Nanosecond(), got.Nanosecond())
+	}
+	a := crockford.AppendFullTime(crockford.Upper, time.Unix(100, 999_999_999), nil)
+	b := crockford.AppendFullTime(crockford.Upper, time.Unix(101, 0), nil)
+	be.True(t, string(a) < string(b))
+
+	bad := make([]byte, 12)
+	bad[8] = 0xff
+	_, err := crockford.DecodeFullTime(crockford.Upper, crockford.Upper.EncodeToString(bad))
+	be.True(t, errors.Is(err, crockford.ErrOverflow))
+	_, err = crockford.DecodeFullTime(crockford.Upper, crockford.Upper.EncodeToString(bad[:11]))
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+}
+
+func TestAppendTime48(t *testing.T) {
+	prev := ""
+	for _, name := range []string{
+		"1970-01-01T00:00:00Z",
+		"2000-01-01T12:00:00Z",
+		"2038-01-19T03:14:07Z",
+		"2100-01-01T00:00:00Z",
+		"9999-12-31T23:59:59Z",
+	} {
+		when, err := time.Parse("2006-01-02T15:04:05Z", name)
+		be.NilErr(t, err)
+		dst := crockford.AppendTime48(crockford.Lower, when, []byte("x"))
+		be.Equal(t, crockford.LenTime48, len(dst)-1)
+		s := string(dst[1:])
+		be.True(t, prev < s)
+		prev = s
+
+		got, err := crockford.DecodeTime48(crockford.Lower, s)
+		be.NilErr(t, err)
+		be.True(t, when.Equal(got))
+	}
+	when := time.Unix(1<<48-1, 0)
+	got, err := crockford.DecodeTime48(crockford.Upper, crockford.ToUpper(string(crockford.AppendTime48(crockford.Lower, when, nil))))
+	be.NilErr(t, err)
+	be.True(t, when.Equal(got))
+
+	_, err = crockford.DecodeTime48(crockford.Lower, "00000000")
+	be.Nonzero(t, err)
+
+	dst := crockford.AppendTime48(crockford.Lower, when, nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = crockford.AppendTime48(crockford.Lower, when, dst[:0])
+	})
+	be.Zero(t, allocs)
+}
+
+func TestContainsExcluded(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want bool
+	}{
+		{"", false},
+		{crockford.UppercaseAlphabet, false},
+		{crockford.LowercaseAlphabet, false},
+		{"01F0-QR80", false},
+		{"I1F0QR80", true},
+		{"i1F0QR80", true},
+		{"01F0QRL0", true},
+		{"01F0QRl0", true},
+		{"O1F0QR80", true},
+		{"o1F0QR80", true},
+		{"01F0QR80U", true},
+		{"01F0QR80u", true},
+	} {
+		be.Equal(t, tc.want, crockford.ContainsExcluded(tc.in))
+	}
+}
+
+func TestAppendChecked(t *testing.T) {
+	for _, in := range []string{"", "\x00", "hello world", "\xff\xff\xff\xff\xff"} {
+		want := crockford.Append(crockford.Lower, []byte("x"), []byte(in))
+		got, err := crockford.AppendChecked(crockford.Lower, []byte("x"), []byte(in))
+		be.NilErr(t, err)
+		be.Equal(t, string(want), string(got))
+	}
+}
+
+func TestAppendObfuscatedTime(t *testing.T) {
+	key := []byte("secret")
+	for _, when := range []time.Time{
+		time.Unix(0, 0),
+		time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		b := crockford.AppendObfuscatedTime(crockford.Lower, when, key, nil)
+		be.Equal(t, crockford.LenTime, len(b))
+		be.Unequal(t, crockford.Time(crockford.Lower, when), string(b))
+
+		got, err := crockford.DecodeObfuscatedTime(crockford.Lower, string(b), key)
+		be.NilErr(t, err)
+		be.True(t, when.Equal(got))
+
+		got, err = crockford.DecodeObfuscatedTime(crockford.Lower, string(b), []byte("wrong"))
+		be.NilErr(t, err)
+		be.False(t, when.Equal(got))
+
+		other := crockford.AppendObfuscatedTime(crockford.Lower, when, []byte("other"), nil)
+		be.Unequal(t, string(b), string(other))
+	}
+	_, err := crockford.DecodeObfuscatedTime(crockford.Lower, "0000", key)
+	be.Nonzero(t, err)
+}
+
+func TestChecksumEncoded(t *testing.T) {
+	for _, tc := range []struct {
+		in                  string
+		encoded, positional byte
+	}{
+		{"", '0', '0'},
+		// 1 + 2 = 3, while 1*32 + 2 = 34
+		{"12", '3', '$'},
+		// transposing symbols does not change the sum
+		{"21", '3', 'W'},
+		// 31 + 31 = 62 = 37 + 25, while 31*32 + 31 = 1023 = 27*37 + 24
+		{"ZZ", 'S', 'R'},
+		{"z-z", 'S', 'R'},
+	} {
+		be.Equal(t, tc.encoded, crockford.ChecksumEncoded(tc.in, true))
+		positional, err := crockford.ChecksumString(crockford.Upper, tc.in)
+		be.NilErr(t, err)
+		be.Equal(t, tc.positional, positional)
+	}
+	be.Equal(t, byte('s'), crockford.ChecksumEncoded("ZZ", false))
+	// Checksum works on the decoded bytes instead
+	b, err := crockford.DecodeString(crockford.Upper, "ZZ")
+	be.NilErr(t, err)
+	be.Equal(t, crockford.Alphabet(true, true)[0xff%37], crockford.Checksum(b, true))
+}
+
+func TestIsChecksumSymbol(t *testing.T) {
+	for c := 0; c < 256; c++ {
+		want := strings.IndexByte("*~$=Uu", byte(c)) >= 0
+		be.Equal(t, want, crockford.IsChecksumSymbol(byte(c)))
+	}
+	for _, c := range []byte(crockford.UppercaseChecksum[:32] + crockford.LowercaseChecksum[:32] + "IiLlOo-") {
+		be.False(t, crockford.IsChecksumSymbol(c))
+	}
+}
+
+func TestSymbolValue(t *testing.T) {
+	want := make(map[byte]int)
+	for i := 0; i < 32; i++ {
+		want[crockford.UppercaseAlphabet[i]] = i
+		want[crockford.LowercaseAlphabet[i]] = i
+	}
+	for _, c := range []byte("IiLl") {
+		want[c] = 1
+	}
+	for _, c := range []byte("Oo") {
+		want[c] = 0
+	}
+	for c := 0; c < 256; c++ {
+		v, ok := crockford.SymbolValue(byte(c))
+		w, wok := want[byte(c)]
+		be.Equal(t, wok, ok)
+		be.Equal(t, w, v)
+	}
+	for _, c := range []byte("*~$=Uu-") {
+		_, ok := crockford.SymbolValue(c)
+		be.False(t, ok)
+	}
+}
+
+func TestAppendFloat64(t *testing.T) {
+	for _, f := range []float64{0, math.Copysign(0, -1), 1, -1.5, math.Pi, math.MaxFloat64,
+		math.SmallestNonzeroFloat64, math.Inf(1), math.Inf(-1), math.NaN(),
+		math.Float64frombits(0x7ff8_0000_0000_0001)} {
+		b := crockford.AppendFloat64(crockford.Upper, f, nil)
+		be.Equal(t, crockford.LenUint64, len(b))
+		got, err := crockford.DecodeFloat64(crockford.Upper, string(b))
+		be.NilErr(t, err)
+		be.Equal(t, math.Float64bits(f), math.Float64bits(got))
+	}
+	_, err := crockford.DecodeFloat64(crockford.Upper, "00")
+	be.Nonzero(t, err)
+}
+
+func TestNormalizeInPlace(t *testing.T) {
+	for _, in := range []string{"", "---", "01F0QR80", "01f0-qr80", "Io-oI-*u", "hello, world!"} {
+		b := []byte(in)
+		got := crockford.NormalizeInPlace(b)
+		be.Equal(t, crockford.Normalized(in), string(got))
+		be.True(t, len(got) == 0 || &got[0] == &b[0])
+		// idempotent
+		be.Equal(t, crockford.Normalized(in), string(crockford.NormalizeInPlace(got)))
+	}
+	b := []byte("d1jp-rv3f-41vp-ywkc-cg")
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = crockford.NormalizeInPlace(b)
+	})
+	be.Zero(t, allocs)
+}
+
+func BenchmarkNormalizeInPlace(b *testing.B) {
+	const in = "d1jp-rv3f-41vp-ywkc-cg"
+	buf := make([]byte, len(in))
+	b.Run("in-place", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copy(buf, in)
+			_ = crockford.NormalizeInPlace(buf)
+		}
+	})
+	b.Run("append", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copy(buf, in)
+			_ = crockford.AppendNormalized(nil, buf)
+		}
+	})
+}
+
+func TestAppendGroupedEncode(t *testing.T) {
+	for _, in := range []string{"", "\x00", "hello", "hello world", strings.Repeat("abc", 100)} {
+		for _, group := range []int{1, 2, 3, 4, 5, 8, 1000} {
+			src := []byte(in)
+			want := crockford.Partition(crockford.Lower.EncodeToString(src), group)
+			got := crockford.AppendGroupedEncode(crockford.Lower, []byte("x"), src, group, '-')
+			be.Equal(t, "x"+want, string(got))
+			got = crockford.AppendGroupedEncode(crockford.Lower, nil, src, group, ' ')
+			be.Equal(t, strings.ReplaceAll(want, "-", " "), string(got))
+
+			allocs := testing.AllocsPerRun(10, func() {
+				got = crockford.AppendGroupedEncode(crockford.Lower, got[:0], src, group, ' ')
+			})
+			be.Zero(t, allocs)
+		}
+	}
+}
+
+func BenchmarkAppendGroupedEncode(b *testing.B) {
+	src := []byte(strings.Repeat("hello world", 100))
+	dst := make([]byte, 0, 4096)
+	b.Run("grouped", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dst = crockford.AppendGroupedEncode(crockford.Lower, dst[:0], src, 4, '-')
+		}
+	})
+	b.Run("partition", func(b *testing.B) {
+		enc := make([]byte, 0, 4096)
+		for i := 0; i < b.N; i++ {
+			enc = crockford.Append(crockford.Lower, enc[:0], src)
+			dst = crockford.AppendPartition(dst[:0], enc, 4)
+		}
+	})
+}
+
+func TestUniformIndex(t *testing.T) {
+	// every byte value once, so rejection is deterministic
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	r := bytes.NewReader(all)
+	counts := make([]int, 22)
+	for {
+		i, err := crockford.UniformIndex(r, 22)
+		if err != nil {
+			be.True(t, err == io.EOF)
+			break
+		}
+		counts[i]++
+	}
+	for _, c := range counts {
+		be.Equal(t, 256/22, c)
+	}
+
+	// chi-square over crypto/rand
+	for _, n := range []int{2, 22, 32, 37} {
+		const samples = 100_000
+		counts := make([]float64, n)
+		for i := 0; i < samples; i++ {
+			v, err := crockford.UniformIndex(rand.Reader, n)
+			be.NilErr(t, err)
+			counts[v]++
+		}
+		expected := float64(samples) / float64(n)
+		chi2 := 0.0
+		for _, c := range counts {
+			chi2 += (c - expected) * (c - expected) / expected
+		}
+		// far beyond the 99.99th percentile for these degrees of freedom
+		limit := float64(n-1) + 10*math.Sqrt(2*float64(n-1))
+		if chi2 > limit {
+			t.Fatalf("n=%d: chi-square %f > %f", n, chi2, limit)
+		}
+	}
+}
+
+func TestAppendTimeString(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"1970-01-01T00:00:00Z", "00000000"},
+		{"2020-01-01T00:00:00Z", "01f0qr80"},
+		{"2020-01-01T01:00:00+01:00", "01f0qr80"},
+		{"2019-12-31T19:00:00-05:00", "01f0qr80"},
+		{"2020-01-01T00:00:00.999Z", "01f0qr80"},
+	} {
+		got, err := crockford.AppendTimeString(crockford.Lower, tc.in, []byte("x"))
+		be.NilErr(t, err)
+		be.Equal(t, "x"+tc.want, string(got))
+	}
+	for _, in := range []string{"", "2020-01-01", "2020-01-01 00:00:00Z", "yesterday"} {
+		got, err := crockford.AppendTimeString(crockford.Lower, in, []byte("x"))
+		be.Nonzero(t, err)
+		be.Equal(t, "x", string(got))
+	}
+}
+
+func TestNormalizedNoChecksum(t *testing.T) {
+	for _, tc := range []struct{ in, normalized, noChecksum string }{
+		{"", "", ""},
+		{"0123=", "0123=", "0123"},
+		{"d1jp-rv3f-41vp-ywkc-cg======", "D1JPRV3F41VPYWKCCG======", "D1JPRV3F41VPYWKCCG"},
+		{"zzzzzzzz*", "ZZZZZZZZ*", "ZZZZZZZZ*"},
+		{"o1=", "01=", "01"},
+	} {
+		be.Equal(t, tc.normalized, crockford.Normalized(tc.in))
+		be.Equal(t, tc.noChecksum, crockford.NormalizedNoChecksum(tc.in))
+	}
+	// with '=' as padding, the data decodes
+	got, err := crockford.DecodeString(crockford.Upper, crockford.NormalizedNoChecksum("d1jprv3f41vpywkccg======"))
+	be.NilErr(t, err)
+	be.Equal(t, "hello world", string(got))
+	// as a checksum symbol, it is verified
+	s := crockford.UpperCk.EncodeToString([]byte{35})
+	be.Equal(t, "4C=", s)
+	be.True(t, crockford.HasValidChecksum(crockford.Upper, crockford.Normalized(s)))
+	be.False(t, crockford.HasValidChecksum(crockford.Upper, crockford.NormalizedNoChecksum(s)))
+}
+
+func TestLengths(t *testing.T) {
+	for _, e := range []*base32.Encoding{crockford.Upper, crockford.Lower} {
+		be.Equal(t, crockford.LenTime, e.EncodedLen(5))
+		be.Equal(t, crockford.LenTime48, e.EncodedLen(6))
+		be.Equal(t, crockford.LenRandom, e.EncodedLen(5))
+		be.Equal(t, crockford.LenMD5, e.EncodedLen(md5.Size))
+		be.Equal(t, crockford.LenBytes16, e.EncodedLen(16))
+		be.Equal(t, crockford.LenUUID, e.EncodedLen(16))
+		be.Equal(t, crockford.LenSHA256, e.EncodedLen(sha256.Size))
+		be.Equal(t, crockford.LenDuration, e.EncodedLen(8))
+		be.Equal(t, crockford.LenUint64, e.EncodedLen(8))
+		be.Equal(t, crockford.LenSequence, e.EncodedLen(8))
+		be.Equal(t, crockford.LenID, e.EncodedLen(10)+1)
+	}
+	id, err := crockford.AppendUUIDString(crockford.Upper, "123e4567-e89b-12d3-a456-426614174000", nil)
+	be.NilErr(t, err)
+	be.Equal(t, crockford.LenUUID, len(id))
+	h := crockford.NewHasher(crockford.Upper, sha256.New())
+	be.Equal(t, crockford.LenSHA256, len(h.Encode(nil)))
+}
+
+func TestAppendNormalizedFastPath(t *testing.T) {
+	for c := 0; c < 256; c++ {
+		for _, in := range []string{string(rune(c)), "01F0" + string([]byte{byte(c)}) + "QR80"} {
+			in := []byte(in)
+			got := crockford.AppendNormalized(nil, in)
+			want := crockford.NormalizeInPlace(append([]byte(nil), in...))
+			be.Equal(t, string(want), string(got))
+		}
+	}
+	be.Equal(t, "x01F0QR80", string(crockford.AppendNormalized([]byte("x"), []byte("01F0QR80"))))
+}
+
+func BenchmarkAppendNormalized(b *testing.B) {
+	for name, in := range map[string]string{
+		"canonical":  "01F0QR80D1JPRV3F41VPYWKCCG",
+		"lowercase":  "01f0qr80d1jprv3f41vpywkccg",
+		"hyphenated": "01F0-QR80-D1JP-RV3F-41VP-YWKC-CG",
+	} {
+		src := []byte(in)
+		dst := make([]byte, 0, len(src))
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dst = crockford.AppendNormalized(dst[:0], src)
+			}
+		})
+	}
+}
+
+func TestDetectCase(t *testing.T) {
+	for _, tc := range []struct {
+		in           string
+		upper, mixed bool
+	}{
+		{"", true, false},
+		{"0123-4567", true, false},
+		{"01F0QR80", true, false},
+		{"01F0-QR80*", true, false},
+		{"01f0qr80", false, false},
+		{"01f0-qr80u", false, false},
+		{"01F0qr80", false, true},
+		{"01f0QR80", false, true},
+		{"ABCDu", false, true},
+	} {
+		upper, mixed := crockford.DetectCase(tc.in)
+		be.Equal(t, tc.upper, upper)
+		be.Equal(t, tc.mixed, mixed)
+	}
+}
+
+func TestRandomWithBytes(t *testing.T) {
+	encoded, raw := crockford.RandomWithBytes(crockford.Lower)
+	be.Equal(t, crockford.LenRandom, len(encoded))
+	be.Equal(t, 5, len(raw))
+	be.Equal(t, crockford.Lower.EncodeToString(raw), encoded)
+
+	encoded2, raw2 := crockford.RandomWithBytes(crockford.Lower)
+	be.Unequal(t, encoded, encoded2)
+	be.Unequal(t, string(raw), string(raw2))
+}
+
+func TestVerificationCode(t *testing.T) {
+	be.Equal(t, 6, len(crockford.VerificationCode(6)))
+	counts := map[byte]int{}
+	const codes, length = 4000, 8
+	for i := 0; i < codes; i++ {
+		code := crockford.VerificationCode(length)
+		be.True(t, crockford.IsCanonical(code))
+		for j := 0; j < len(code); j++ {
+			counts[code[j]]++
+		}
+	}
+	be.Equal(t, 32, len(counts))
+	// each symbol is expected 1000 times, with a standard deviation near 31
+	for _, n := range counts {
+		be.True(t, n > 800 && n < 1200)
+	}
+}
+
+func TestRandomLeadingLetter(t *testing.T) {
+	firsts := map[byte]int{}
+	for i := 0; i < 2000; i++ {
+		s := crockford.RandomLeadingLetter(crockford.Upper, 1+i%10)
+		be.Equal(t, 1+i%10, len(s))
+		be.True(t, s[0] >= 'A' && s[0] <= 'Z')
+		firsts[s[0]]++
+		for j := 0; j < len(s); j++ {
+			_, ok := crockford.SymbolValue(s[j])
+			be.True(t, ok)
+		}
+	}
+	be.Equal(t, 22, len(firsts))
+	s := crockford.RandomLeadingLetter(crockford.Lower, 16)
+	be.True(t, s[0] >= 'a' && s[0] <= 'z')
+	be.Equal(t, crockford.ToLower(s), s)
+}
+
+func TestSeededRandom(t *testing.T) {
+	a := crockford.SeededRandom(crockford.Upper, 1)
+	be.Equal(t, crockford.LenRandom, len(a))
+	be.Equal(t, a, crockford.SeededRandom(crockford.Upper, 1))
+	be.Equal(t, crockford.ToLower(a), crockford.SeededRandom(crockford.Lower, 1))
+	be.Unequal(t, a, crockford.SeededRandom(crockford.Upper, 2))
+	// math/rand sources are stable, so golden values hold
+	be.Equal(t, "ABYZR1S1", a)
+}
+
+func TestRandomDualCase(t *testing.T) {
+	upper, lower := crockford.RandomDualCase()
+	be.Equal(t, crockford.LenRandom, len(upper))
+	be.Equal(t, crockford.ToLower(upper), lower)
+	be.Equal(t, crockford.ToUpper(lower), upper)
+	a, err := crockford.DecodeString(crockford.Upper, upper)
+	be.NilErr(t, err)
+	b, err := crockford.DecodeString(crockford.Lower, lower)
+	be.NilErr(t, err)
+	be.Equal(t, string(a), string(b))
+
+	upper2, _ := crockford.RandomDualCase()
+	be.Unequal(t, upper, upper2)
+}
+
+func TestAppendRandom2(t *testing.T) {
+	full, added := crockford.AppendRandom2(crockford.Lower, []byte("id:"))
+	be.Equal(t, "id:", string(full[:3]))
+	be.Equal(t, crockford.LenRandom, len(added))
+	be.Equal(t, string(full[3:]), string(added))
+	be.True(t, &full[3] == &added[0])
+
+	allocs := testing.AllocsPerRun(100, func() {
+		full, added = crockford.AppendRandom2(crockford.Lower, full[:0])
+	})
+	be.Zero(t, allocs)
+	be.Equal(t, string(full), string(added))
+}
+
+func TestDeterministicID(t *testing.T) {
+	id := crockford.DeterministicID(crockford.Upper, []byte("ab"), []byte("c"))
+	be.Equal(t, crockford.LenBytes16, len(id))
+	be.Equal(t, id, crockford.DeterministicID(crockford.Upper, []byte("ab"), []byte("c")))
+	be.Equal(t, crockford.ToLower(id), crockford.DeterministicID(crockford.Lower, []byte("ab"), []byte("c")))
+
+	for _, fields := range [][][]byte{
+		{[]byte("a"), []byte("bc")},
+		{[]byte("abc")},
+		{[]byte("ab"), []byte("c"), nil},
+		{nil, []byte("ab"), []byte("c")},
+		{[]byte("c"), []byte("ab")},
+	} {
+		be.Unequal(t, id, crockford.DeterministicID(crockford.Upper, fields...))
+	}
+	be.Unequal(t, crockford.DeterministicID(crockford.Upper), crockford.DeterministicID(crockford.Upper, nil))
+}
+
+// switchSymbolValue is SymbolValue as it was before the symbol table,
+// for comparison in BenchmarkSymbolValue
+func switchSymbolValue(c byte) (int, bool) {
+	switch c {
+	case 'I', 'i', 'L', 'l':
+		return 1, true
+	case 'O', 'o':
+		return 0, true
+	}
+	if c >= 'a' && c <= 'z' {
+		c = c - 'a' + 'A'
+	}
+	if i := strings.IndexByte(crockford.UppercaseAlphabet, c); i >= 0 {
+		return i, true
+	}
+	return 0, false
+}
+
+func BenchmarkSymbolValue(b *testing.B) {
+	s := strings.Repeat("d1jp-rv3f-41vp-ywkc-cg", 100)
+	b.Run("ChecksumString", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = crockford.ChecksumString(crockford.Upper, s)
+		}
+	})
+	b.Run("SymbolValue", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < len(s); j++ {
+				_, _ = crockford.SymbolValue(s[j])
+			}
+		}
+	})
+	b.Run("switch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < len(s); j++ {
+				_, _ = switchSymbolValue(s[j])
+			}
+		}
+	})
+}
+
+func TestSymbolValueConcurrent(t *testing.T) {
+	var values [8]int
+	var oks [8]bool
+	var wg sync.WaitGroup
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], oks[i] = crockford.SymbolValue('Z')
+		}(i)
+	}
+	wg.Wait()
+	for i := range values {
+		be.True(t, oks[i])
+		be.Equal(t, 31, values[i])
+	}
+}
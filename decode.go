@@ -0,0 +1,422 @@
+package crockford
+
+import (
+	"bufio"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Decoding errors. Errors returned by the decoding functions
+// match one of these with errors.Is.
+// Errors from encoding/base32 are wrapped and available with errors.As.
+var (
+	ErrInvalidLength    = errors.New("crockford: invalid length")
+	ErrInvalidChar      = errors.New("crockford: invalid symbol")
+	ErrChecksumMismatch = errors.New("crockford: checksum mismatch")
+	ErrOverflow         = errors.New("crockford: value overflows")
+)
+
+// decodeError wraps an error from encoding/base32 with a sentinel
+type decodeError struct {
+	kind, err error
+}
+
+func (de *decodeError) Error() string { return de.kind.Error() + ": " + de.err.Error() }
+
+func (de *decodeError) Unwrap() error { return de.err }
+
+func (de *decodeError) Is(target error) bool { return target == de.kind }
+
+// wrapErr converts an error from encoding/base32 into a decodeError
+func wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &decodeError{ErrInvalidChar, err}
+}
+
+// validLen reports whether n symbols can be produced by encoding whole bytes
+func validLen(n int) bool {
+	switch n % 8 {
+	case 1, 3, 6:
+		return false
+	}
+	return true
+}
+
+// lenError reports a decoded length of got bytes when want were expected
+func lenError(got, want int) error {
+	return fmt.Errorf("%w %d, want %d bytes", ErrInvalidLength, got, want)
+}
+
+// DecodeString returns the bytes represented by the Crockford encoded s.
+// See AppendDecoded.
+func DecodeString(e *base32.Encoding, s string) ([]byte, error) {
+	return AppendDecoded(e, nil, []byte(s))
+}
+
+// AppendDecoded normalizes src for the alphabet of e,
+// decodes it, and appends the result onto dst.
+// It returns ErrInvalidLength if the normalized length could not have been
+// produced by encoding whole bytes, and ErrInvalidChar for undecodable symbols.
+// Custom alphabets are normalized as by AppendNormalizedFor,
+// keeping the padding character of e, if any.
+// For the standard alphabets, checksum symbols are kept by normalization
+// and so cause an error rather than being silently dropped.
+func AppendDecoded(e *base32.Encoding, dst, src []byte) ([]byte, error) {
+	alphabet := decodeAlphabet(e)
+	if pad := padChar(e); pad != 0 && strings.IndexByte(alphabet, pad) < 0 {
+		alphabet += string(pad)
+	}
+	n := e.DecodedLen(len(src))
+	dst = grow(dst, n+len(src))
+	// Normalize into the scratch space after the decoded bytes
+	off := len(dst) + n
+	scratch := AppendNormalizedFor(dst[off:off], src, alphabet)
+	if !validLen(len(scratch)) {
+		return dst, fmt.Errorf("%w %d symbols", ErrInvalidLength, len(scratch))
+	}
+	m, err := e.Decode(dst[len(dst):off], scratch)
+	if err != nil {
+		return dst, wrapErr(err)
+	}
+	return dst[:len(dst)+m], nil
+}
+
+// DecodePartial is a best-effort DecodeString for recovering data
+// from truncated or corrupted input. It decodes the whole bytes
+// encoded by the longest run of symbols at the start of s,
+// skipping hyphens, and returns them with validPrefixLen, the length
+// of the prefix of s that they were decoded from.
+// The error reports why decoding stopped early: ErrInvalidChar for
+// a byte that is not a data symbol, or ErrInvalidLength if s ends with
+// symbols that do not make up a whole byte. It is nil if all of s decoded.
+// If e uses padding, padding at the end of s is accepted but not required.
+// Corruption that leaves valid symbols, such as a mistyped letter,
+// cannot be detected; use a checksum for that.
+func DecodePartial(e *base32.Encoding, s string) (decoded []byte, validPrefixLen int, err error) {
+	alphabet := decodeAlphabet(e)
+	pad := padChar(e)
+	b := make([]byte, 0, len(s))
+	// ends[i] is the length of the prefix of s holding the first i+1 symbols
+	ends := make([]int, 0, len(s))
+	padded := false
+	for i := 0; i < len(s) && err == nil && !padded; i++ {
+		c := normFor(s[i], alphabet)
+		switch {
+		case s[i] == '-':
+		case pad != 0 && s[i] == pad && strings.Trim(s[i:], string(pad)) == "":
+			padded = true
+		case c == 0 || strings.IndexByte(alphabet, c) >= 32:
+			err = fmt.Errorf("%w %q at byte %d", ErrInvalidChar, s[i], i)
+		default:
+			b = append(b, c)
+			ends = append(ends, i+1)
+		}
+	}
+	n := len(b) * 5 / 8
+	// the number of symbols for n bytes without padding
+	symbols := (n*8 + 4) / 5
+	if err == nil && symbols != len(b) {
+		err = fmt.Errorf("%w %d symbols", ErrInvalidLength, len(b))
+	}
+	switch {
+	case err == nil && padded:
+		validPrefixLen = len(s)
+	case symbols > 0:
+		validPrefixLen = ends[symbols-1]
+	}
+	// e decodes only whole blocks if it uses padding
+	b = b[:symbols]
+	for pad != 0 && len(b)%8 != 0 {
+		b = append(b, pad)
+	}
+	decoded = make([]byte, n)
+	if _, derr := e.Decode(decoded, b); derr != nil {
+		return nil, 0, wrapErr(derr)
+	}
+	return decoded, validPrefixLen, err
+}
+
+// padChar returns the padding character of e, or 0 if it has none
+func padChar(e *base32.Encoding) byte {
+	if e.EncodedLen(1) != 8 {
+		return 0
+	}
+	var buf [8]byte
+	e.Encode(buf[:], []byte{0})
+	return buf[7]
+}
+
+// DecodeURL is DecodeString for s taken from a URL, which is unescaped
+// as by url.QueryUnescape first, so that percent-encoded separators
+// such as %2D are removed by normalization. Invalid percent-encoding
+// returns an error matching ErrInvalidChar.
+func DecodeURL(e *base32.Encoding, s string) ([]byte, error) {
+	u, err := url.QueryUnescape(s)
+	if err != nil {
+		return nil, &decodeError{ErrInvalidChar, err}
+	}
+	return DecodeString(e, u)
+}
+
+// DecodeLenientU is DecodeString, but reads U and u as the data symbol V
+// instead of rejecting them. It is for interoperating with non-conforming
+// producers that put U in their data alphabet; U and V cannot be told apart
+// afterwards, so only use it for input known to come from such a producer.
+// DecodeString remains strict.
+func DecodeLenientU(e *base32.Encoding, s string) ([]byte, error) {
+	b := []byte(s)
+	for i, c := range b {
+		switch c {
+		case 'U':
+			b[i] = 'V'
+		case 'u':
+			b[i] = 'v'
+		}
+	}
+	return AppendDecoded(e, nil, b)
+}
+
+// DecodedLenString returns the number of bytes that decoding s will produce,
+// counting only the symbols that Normalized keeps, so hyphens and
+// other separators do not inflate the result as with Upper.DecodedLen(len(s)).
+func DecodedLenString(s string) int {
+	return symbolCount(s) * 5 / 8
+}
+
+// symbolCount returns the number of bytes of s that Normalized keeps
+func symbolCount(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if normUpper(s[i]) != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// DecodedByteLen is DecodedLenString, but returns ErrInvalidLength
+// if the number of symbols could not have been produced by encoding
+// whole bytes, that is, if it is 1, 3, or 6 more than a multiple of 8.
+func DecodedByteLen(s string) (int, error) {
+	n := symbolCount(s)
+	if !validLen(n) {
+		return 0, fmt.Errorf("%w %d symbols", ErrInvalidLength, n)
+	}
+	return n * 5 / 8, nil
+}
+
+// LooksTruncated reports whether s has fewer symbols after normalization
+// than expected, typically one of the length constants such as LenULID or LenID.
+// It helps spot IDs cut off by a too narrow database column,
+// since a truncated ID may still decode without error.
+func LooksTruncated(s string, expected int) bool {
+	return symbolCount(s) < expected
+}
+
+// ValidateGrouped checks that s is made of data symbols in groups of
+// exactly group symbols separated by sep, with only the last group allowed
+// to be shorter, as produced by Partition or AppendGroupedEncode.
+// Unlike Normalized, it reports misplaced separators instead of removing them.
+// Errors match ErrInvalidChar. It panics if group < 1.
+func ValidateGrouped(s string, group int, sep byte) error {
+	if group < 1 {
+		panic("invalid group")
+	}
+	for i := 0; i < len(s); i++ {
+		wantSep := i%(group+1) == group
+		if c := s[i]; c == sep {
+			if !wantSep || i == len(s)-1 {
+				return fmt.Errorf("%w: misplaced separator at byte %d", ErrInvalidChar, i)
+			}
+		} else if wantSep {
+			return fmt.Errorf("%w: missing separator at byte %d", ErrInvalidChar, i)
+		} else if _, ok := SymbolValue(c); !ok {
+			return fmt.Errorf("%w %q at byte %d", ErrInvalidChar, c, i)
+		}
+	}
+	return nil
+}
+
+// ValueBits returns the value of s read as a big endian base 32 number,
+// with its symbols taken from the alphabet of e after normalization.
+// It returns ErrOverflow if the value needs more than maxBits bits.
+// It panics unless 1 <= maxBits <= 64.
+func ValueBits(e *base32.Encoding, s string, maxBits int) (uint64, error) {
+	if maxBits < 1 || maxBits > 64 {
+		panic("invalid bit width")
+	}
+	alphabet := decodeAlphabet(e)
+	var v uint64
+	next := func(n int, c byte) error {
+		if n >= 32 {
+			return fmt.Errorf("%w %q", ErrInvalidChar, c)
+		}
+		if v>>(64-5) != 0 {
+			return ErrOverflow
+		}
+		v = v<<5 | uint64(n)
+		return nil
+	}
+	if alphabet == UppercaseChecksum || alphabet == LowercaseChecksum {
+		// the standard alphabets are case insensitive, so use the symbol table
+		table := symbolTable()
+		for i := 0; i < len(s); i++ {
+			if n := table[s[i]]; n != noSymbol {
+				if err := next(int(n), s[i]); err != nil {
+					return 0, err
+				}
+			}
+		}
+	} else {
+		for _, c := range AppendNormalizedFor(nil, []byte(s), alphabet) {
+			if err := next(strings.IndexByte(alphabet, c), c); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if maxBits < 64 && v>>maxBits != 0 {
+		return 0, ErrOverflow
+	}
+	return v, nil
+}
+
+// ValidateAll checks that each of ids can be normalized and decoded.
+// It returns a slice parallel to ids, holding nil for each valid ID
+// and an error describing the problem for each invalid one.
+func ValidateAll(ids []string) []error {
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		if Normalized(id) == "" {
+			errs[i] = fmt.Errorf("crockford: invalid ID %q: %w: no symbols", id, ErrInvalidLength)
+			continue
+		}
+		if _, err := DecodeString(Upper, id); err != nil {
+			errs[i] = fmt.Errorf("crockford: invalid ID %q: %w", id, err)
+		}
+	}
+	return errs
+}
+
+// DecodeScanner decodes each token from sc, as by AppendDecoded,
+// and calls fn with the result. Tokens are split by the split function
+// of sc, which is lines by default. The slice passed to fn is reused
+// by the next call and must not be retained.
+// DecodeScanner stops at the first error from decoding, fn, or sc.
+func DecodeScanner(e *base32.Encoding, sc *bufio.Scanner, fn func(decoded []byte) error) error {
+	var buf []byte
+	for sc.Scan() {
+		var err error
+		buf, err = AppendDecoded(e, buf[:0], sc.Bytes())
+		if err != nil {
+			return fmt.Errorf("crockford: invalid ID %q: %w", sc.Text(), err)
+		}
+		if err = fn(buf); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// alphabetSrc encodes to each of the 32 symbols in order
+var alphabetSrc = [20]byte{
+	0x00, 0x44, 0x32, 0x14, 0xc7, 0x42, 0x54, 0xb6, 0x35, 0xcf,
+	0x84, 0x65, 0x3a, 0x56, 0xd7, 0xc6, 0x75, 0xbe, 0x77, 0xdf,
+}
+
+// decodeAlphabet returns the symbols accepted by normalization before decoding with e
+func decodeAlphabet(e *base32.Encoding) string {
+	switch e {
+	case Upper:
+		return UppercaseChecksum
+	case Lower:
+		return LowercaseChecksum
+	}
+	var buf [32]byte
+	e.Encode(buf[:], alphabetSrc[:])
+	switch string(buf[:]) {
+	case UppercaseAlphabet:
+		return UppercaseChecksum
+	case LowercaseAlphabet:
+		return LowercaseChecksum
+	}
+	return string(buf[:])
+}
+
+var rawStd = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// FromStdBase32 converts s from the RFC 4648 standard base 32 alphabet
+// to the uppercase Crockford alphabet. Padding in s is optional.
+func FromStdBase32(s string) (string, error) {
+	b, err := rawStd.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return "", wrapErr(err)
+	}
+	return Upper.EncodeToString(b), nil
+}
+
+// ToStdBase32 converts the Crockford encoded s to
+// the padded RFC 4648 standard base 32 alphabet.
+// s is normalized first.
+func ToStdBase32(s string) (string, error) {
+	b, err := DecodeString(Upper, s)
+	if err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
+
+// DecodeTo normalizes s, decodes it, and writes the result to w
+// in small chunks, without holding the whole decoded value in memory.
+// It returns the number of bytes written and the first error from
+// decoding or from w. A short write without an error is reported
+// as io.ErrShortWrite.
+func DecodeTo(e *base32.Encoding, w io.Writer, s string) (int, error) {
+	alphabet := decodeAlphabet(e)
+	var (
+		sym   [512]byte // a multiple of 8 symbols
+		out   [320]byte
+		nsym  int
+		total int
+	)
+	flush := func() error {
+		if !validLen(nsym) {
+			return fmt.Errorf("%w %d symbols", ErrInvalidLength, nsym)
+		}
+		n, err := e.Decode(out[:], sym[:nsym])
+		nsym = 0
+		if err != nil {
+			return wrapErr(err)
+		}
+		m, err := w.Write(out[:n])
+		total += m
+		if err == nil && m < n {
+			err = io.ErrShortWrite
+		}
+		return err
+	}
+	for i := 0; i < len(s); i++ {
+		c := normFor(s[i], alphabet)
+		if c == 0 {
+			continue
+		}
+		sym[nsym] = c
+		nsym++
+		if nsym == len(sym) {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+	if nsym > 0 {
+		if err := flush(); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,440 @@
+package crockford_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base32"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func TestDecodeString(t *testing.T) {
+	for _, tc := range []struct {
+		e       *base32.Encoding
+		in, out string
+	}{
+		{crockford.Upper, "", ""},
+		{crockford.Lower, "", ""},
+		{crockford.Upper, "D1JPRV3F41VPYWKCCG", "hello world"},
+		{crockford.Lower, "D1JPRV3F41VPYWKCCG", "hello world"},
+		{crockford.Upper, "d1jp-rv3f-41vp-ywkc-cg", "hello world"},
+		{crockford.Lower, "zzzz-zzzz", "\xff\xff\xff\xff\xff"},
+		{crockford.Upper, "oo", "\x00"},
+		{crockford.Upper, "I0", "\x08"},
+	} {
+		got, err := crockford.DecodeString(tc.e, tc.in)
+		be.NilErr(t, err)
+		be.Equal(t, tc.out, string(got))
+	}
+	for _, in := range []string{"00*", "0U", "0000000U", "$"} {
+		_, err := crockford.DecodeString(crockford.Upper, in)
+		be.Nonzero(t, err)
+	}
+}
+
+func TestDecodeScanner(t *testing.T) {
+	var got []string
+	sc := bufio.NewScanner(strings.NewReader("d1jp-rv3f-41vp-ywkc-cg\nCSQPYRK1\n\nzzzz-zzzz\n"))
+	err := crockford.DecodeScanner(crockford.Lower, sc, func(b []byte) error {
+		got = append(got, string(b))
+		return nil
+	})
+	be.NilErr(t, err)
+	be.AllEqual(t, []string{"hello world", "fooba", "", "\xff\xff\xff\xff\xff"}, got)
+
+	// words instead of lines
+	got = nil
+	sc = bufio.NewScanner(strings.NewReader("CSQPYRK1 d1jp-rv3f-41vp-ywkc-cg"))
+	sc.Split(bufio.ScanWords)
+	err = crockford.DecodeScanner(crockford.Upper, sc, func(b []byte) error {
+		got = append(got, string(b))
+		return nil
+	})
+	be.NilErr(t, err)
+	be.AllEqual(t, []string{"fooba", "hello world"}, got)
+
+	got = nil
+	sc = bufio.NewScanner(strings.NewReader("CSQPYRK1\nCSQ*\nCSQPYRK1\n"))
+	err = crockford.DecodeScanner(crockford.Upper, sc, func(b []byte) error {
+		got = append(got, string(b))
+		return nil
+	})
+	be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+	be.AllEqual(t, []string{"fooba"}, got)
+
+	errStop := errors.New("stop")
+	sc = bufio.NewScanner(strings.NewReader("CSQPYRK1\nCSQPYRK1\n"))
+	n := 0
+	err = crockford.DecodeScanner(crockford.Upper, sc, func(b []byte) error {
+		n++
+		return errStop
+	})
+	be.True(t, errors.Is(err, errStop))
+	be.Equal(t, 1, n)
+}
+
+func TestDecodePartial(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    string
+		n       int
+		wantErr error
+	}{
+		{"", "", 0, nil},
+		{"d1jp-rv3f-41vp-ywkc-cg", "hello world", 22, nil},
+		{"d1jp-rv3f-41vp-yw#kc-cg", "hello wo", 16, crockford.ErrInvalidChar},
+		{"D1JPRV3F41VPYWKCC", "hello worl", 16, crockford.ErrInvalidLength},
+		{"D1JP*RV3F", "he", 4, crockford.ErrInvalidChar},
+		{"#D1JP", "", 0, crockford.ErrInvalidChar},
+		{"D", "", 0, crockford.ErrInvalidLength},
+	} {
+		got, n, err := crockford.DecodePartial(crockford.Upper, tc.in)
+		be.Equal(t, tc.want, string(got))
+		be.Equal(t, tc.n, n)
+		if tc.wantErr == nil {
+			be.NilErr(t, err)
+		} else {
+			be.True(t, errors.Is(err, tc.wantErr))
+		}
+	}
+}
+
+func TestDecodePartialPadded(t *testing.T) {
+	padded := base32.NewEncoding(crockford.UppercaseAlphabet)
+	for _, tc := range []struct {
+		e       *base32.Encoding
+		in      string
+		want    string
+		n       int
+		wantErr error
+	}{
+		{base32.StdEncoding, "MZXW6===", "foo", 8, nil},
+		{base32.StdEncoding, "MZXW6", "foo", 5, nil},
+		{base32.StdEncoding, "MZXW6YQ=", "foob", 8, nil},
+		{base32.StdEncoding, "MZXW6=Y=", "foo", 5, crockford.ErrInvalidChar},
+		{padded, "D1JPRV3F41", "hello ", 10, nil},
+		{padded, "D1JPRV3F4", "hello", 8, crockford.ErrInvalidLength},
+		{padded, "D1JPRV3F41VPYWKCCG======", "hello world", 24, nil},
+		{padded, "d1jp-rv3f", "hello", 9, nil},
+	} {
+		got, n, err := crockford.DecodePartial(tc.e, tc.in)
+		be.Equal(t, tc.want, string(got))
+		be.Equal(t, tc.n, n)
+		if tc.wantErr == nil {
+			be.NilErr(t, err)
+		} else {
+			be.True(t, errors.Is(err, tc.wantErr))
+		}
+	}
+}
+
+func TestDecodeURL(t *testing.T) {
+	for _, in := range []string{
+		"D1JPRV3F41VPYWKCCG",
+		"d1jp-rv3f-41vp-ywkc-cg",
+		"d1jp%2drv3f%2d41vp%2dywkc%2dcg",
+		"D1JP%2DRV3F%2D41VP%2DYWKC%2DCG",
+		"d1jp+rv3f+41vp+ywkc+cg",
+		"%44%31JPRV3F41VPYWKCCG",
+	} {
+		got, err := crockford.DecodeURL(crockford.Upper, in)
+		be.NilErr(t, err)
+		be.Equal(t, "hello world", string(got))
+	}
+	for _, in := range []string{"D1JP%2", "D1JP%zz", "%"} {
+		_, err := crockford.DecodeURL(crockford.Upper, in)
+		be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+	}
+}
+
+func TestDecodeLenientU(t *testing.T) {
+	for _, tc := range []struct {
+		e        *base32.Encoding
+		in, want string
+	}{
+		{crockford.Upper, "D1JPRU3F41UPYWKCCG", "D1JPRV3F41VPYWKCCG"},
+		{crockford.Lower, "d1jpru3f-41upywkccg", "D1JPRV3F41VPYWKCCG"},
+		{crockford.Upper, "0000000U", "0000000V"},
+		{crockford.Upper, "uu", "VV"},
+	} {
+		got, err := crockford.DecodeLenientU(tc.e, tc.in)
+		be.NilErr(t, err)
+		want, err := crockford.DecodeString(crockford.Upper, tc.want)
+		be.NilErr(t, err)
+		be.Equal(t, string(want), string(got))
+	}
+	_, err := crockford.DecodeLenientU(crockford.Upper, "000*")
+	be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+}
+
+func TestAppendDecoded(t *testing.T) {
+	src := []byte("d1jp-rv3f-41vp-ywkc-cg")
+	b, err := crockford.AppendDecoded(crockford.Lower, []byte("abc"), src)
+	be.NilErr(t, err)
+	be.Equal(t, "abchello world", string(b))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		b, err = crockford.AppendDecoded(crockford.Upper, b[:0], src)
+	})
+	be.Zero(t, allocs)
+	be.NilErr(t, err)
+	be.Equal(t, "hello world", string(b))
+}
+
+func TestCustomAlphabet(t *testing.T) {
+	const alphabet = "zyxwvtsrqpnmkjhgfedcba9876543210"
+	e := base32.NewEncoding(alphabet).WithPadding(base32.NoPadding)
+	s := e.EncodeToString([]byte("hello world"))
+	be.Equal(t, s, crockford.NormalizedFor(crockford.ToUpper(s), alphabet))
+
+	got, err := crockford.DecodeString(e, crockford.Partition(crockford.ToUpper(s), 4))
+	be.NilErr(t, err)
+	be.Equal(t, "hello world", string(got))
+
+	be.Equal(t, "10ab", crockford.NormalizedFor("Io-AB", alphabet))
+	be.Equal(t, "IOAB", crockford.NormalizedFor("io-ab", "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"))
+	be.Equal(t, crockford.Normalized("01-ab*u"), crockford.NormalizedFor("01-ab*u", crockford.UppercaseChecksum))
+
+	// padded encodings decode their own output
+	for _, e := range []*base32.Encoding{
+		base32.NewEncoding(alphabet),
+		base32.NewEncoding(alphabet).WithPadding('#'),
+		base32.StdEncoding,
+		base32.HexEncoding,
+	} {
+		for _, in := range []string{"", "x", "hi", "hello", "hello world"} {
+			s := e.EncodeToString([]byte(in))
+			got, err := crockford.DecodeString(e, s)
+			be.NilErr(t, err)
+			be.Equal(t, in, string(got))
+		}
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	be.Zero(t, len(crockford.ValidateAll(nil)))
+	errs := crockford.ValidateAll([]string{
+		"D1JPRV3F41VPYWKCCG",
+		"",
+		"d1jp-rv3f",
+		"---",
+		"ZZZZ*ZZZ",
+		"01F0QR80",
+	})
+	be.Equal(t, 6, len(errs))
+	be.NilErr(t, errs[0])
+	be.True(t, errors.Is(errs[1], crockford.ErrInvalidLength))
+	be.NilErr(t, errs[2])
+	be.True(t, errors.Is(errs[3], crockford.ErrInvalidLength))
+	be.True(t, errors.Is(errs[4], crockford.ErrInvalidChar))
+	be.In(t, `"ZZZZ*ZZZ"`, errs[4].Error())
+	be.NilErr(t, errs[5])
+}
+
+func TestValueBits(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		maxBits int
+		want    uint64
+		err     error
+	}{
+		{"", 1, 0, nil},
+		{"1", 1, 1, nil},
+		{"2", 1, 0, crockford.ErrOverflow},
+		{"10", 6, 32, nil},
+		{"16J", 11, 1234, nil},
+		{"16J", 10, 0, crockford.ErrOverflow},
+		{"1-6-j", 64, 1234, nil},
+		{"ZZZZZZZZ", 40, 1<<40 - 1, nil},
+		{"ZZZZZZZZ", 39, 0, crockford.ErrOverflow},
+		{"FZZZZZZZZZZZZ", 64, 1<<64 - 1, nil},
+		{"G000000000000", 64, 0, crockford.ErrOverflow},
+		{"0000000000000001", 1, 1, nil},
+	} {
+		got, err := crockford.ValueBits(crockford.Upper, tc.in, tc.maxBits)
+		be.True(t, errors.Is(err, tc.err))
+		be.Equal(t, tc.want, got)
+	}
+	got, err := crockford.ValueBits(crockford.Lower, "16J", 64)
+	be.NilErr(t, err)
+	be.Equal(t, 1234, got)
+	_, err = crockford.ValueBits(crockford.Upper, "16J*", 64)
+	be.Nonzero(t, err)
+}
+
+func TestErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err  error
+		want error
+	}{
+		"DecodeString/length": {errOnly(crockford.DecodeString(crockford.Upper, "0")), crockford.ErrInvalidLength},
+		"DecodeString/char":   {errOnly(crockford.DecodeString(crockford.Upper, "0*")), crockford.ErrInvalidChar},
+		"ChecksumString":      {errOnly(crockford.ChecksumString(crockford.Upper, "0U")), crockford.ErrInvalidChar},
+		"ValueBits/char":      {errOnly(crockford.ValueBits(crockford.Upper, "0$", 64)), crockford.ErrInvalidChar},
+		"ValueBits/overflow":  {errOnly(crockford.ValueBits(crockford.Upper, "2", 1)), crockford.ErrOverflow},
+		"DecodeUint64":        {errOnly(crockford.DecodeUint64(crockford.Upper, "00")), crockford.ErrInvalidLength},
+		"DecodeUint64LE":      {errOnly(crockford.DecodeUint64LE(crockford.Upper, "0000000*")), crockford.ErrInvalidChar},
+		"DecodeDuration":      {errOnly(crockford.DecodeDuration(crockford.Upper, "00")), crockford.ErrInvalidLength},
+		"DecodeFloat64":       {errOnly(crockford.DecodeFloat64(crockford.Upper, "00")), crockford.ErrInvalidLength},
+		"DecodeTime48":        {errOnly(crockford.DecodeTime48(crockford.Upper, "00")), crockford.ErrInvalidLength},
+		"DecodeObfuscatedTime": {errOnly(crockford.DecodeObfuscatedTime(crockford.Upper, "00", nil)),
+			crockford.ErrInvalidLength},
+		"DecodePacked": {errOnly(crockford.DecodePacked(crockford.Upper, "00", []int{16})), crockford.ErrInvalidLength},
+		"SplitTimeRandom": {errOnly2(crockford.SplitTimeRandom(crockford.Upper, "00", 5)),
+			crockford.ErrInvalidLength},
+	} {
+		t.Run(name, func(t *testing.T) {
+			be.True(t, errors.Is(tc.err, tc.want))
+		})
+	}
+
+	_, err := crockford.DecodeString(crockford.Upper, "00*0")
+	var cerr base32.CorruptInputError
+	be.True(t, errors.As(err, &cerr))
+	be.Equal(t, 2, int64(cerr))
+
+	_, _, _, err = crockford.SplitID(crockford.Upper, "0")
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+	_, _, _, err = crockford.SplitID(crockford.Upper, "0000000000000000U")
+	be.NilErr(t, err)
+	_, _, _, err = crockford.SplitID(crockford.Upper, "000000000000000U0")
+	be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+}
+
+func errOnly[T any](_ T, err error) error { return err }
+
+func errOnly2[T, U any](_ T, _ U, err error) error { return err }
+
+func TestDecodedLenString(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"---",
+		"00",
+		"ZZZZZZZZ",
+		"zzzz-zzzz",
+		"D1JPRV3F41VPYWKCCG",
+		"d1jp-rv3f-41vp-ywkc-cg",
+		"01F0 QR80 . 01F0 QR80",
+	} {
+		b, err := crockford.DecodeString(crockford.Upper, in)
+		be.NilErr(t, err)
+		be.Equal(t, len(b), crockford.DecodedLenString(in))
+	}
+	be.Equal(t, 11, crockford.DecodedLenString("d1jp-rv3f-41vp-ywkc-cg"))
+	be.True(t, crockford.Upper.DecodedLen(len("d1jp-rv3f-41vp-ywkc-cg")) > 11)
+}
+
+func TestDecodedByteLen(t *testing.T) {
+	for symbols := 0; symbols <= 24; symbols++ {
+		s := crockford.Partition(strings.Repeat("z", symbols), 4)
+		n, err := crockford.DecodedByteLen(s)
+		switch symbols % 8 {
+		case 1, 3, 6:
+			be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+		default:
+			be.NilErr(t, err)
+			b, err := crockford.DecodeString(crockford.Lower, s)
+			be.NilErr(t, err)
+			be.Equal(t, len(b), n)
+		}
+	}
+}
+
+func TestStdBase32(t *testing.T) {
+	for _, in := range []string{"", "f", "fo", "foo", "foob", "fooba", "foobar", "hello world"} {
+		std := base32.StdEncoding.EncodeToString([]byte(in))
+		want := crockford.Upper.EncodeToString([]byte(in))
+		for _, s := range []string{std, strings.TrimRight(std, "=")} {
+			got, err := crockford.FromStdBase32(s)
+			be.NilErr(t, err)
+			be.Equal(t, want, got)
+		}
+		got, err := crockford.ToStdBase32(crockford.ToLower(want))
+		be.NilErr(t, err)
+		be.Equal(t, std, got)
+	}
+	// RFC 4648 test vector
+	got, err := crockford.FromStdBase32("MZXW6YTBOI======")
+	be.NilErr(t, err)
+	be.Equal(t, crockford.Upper.EncodeToString([]byte("foobar")), got)
+
+	for _, in := range []string{"MZXW6YTBO1", "mzxw6ytboi", "MZXW6YTB-OI"} {
+		_, err = crockford.FromStdBase32(in)
+		be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+	}
+	_, err = crockford.ToStdBase32("ZZ*")
+	be.Nonzero(t, err)
+}
+
+type shortWriter struct{ n int }
+
+func (sw *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > sw.n {
+		n := sw.n
+		sw.n = 0
+		return n, nil
+	}
+	sw.n -= len(p)
+	return len(p), nil
+}
+
+func TestDecodeTo(t *testing.T) {
+	big := bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog. "), 1000)
+	for _, in := range [][]byte{nil, []byte("hello world"), big} {
+		s := crockford.Partition(crockford.Lower.EncodeToString(in), 4)
+		var buf bytes.Buffer
+		n, err := crockford.DecodeTo(crockford.Upper, &buf, s)
+		be.NilErr(t, err)
+		be.Equal(t, len(in), n)
+		be.Equal(t, string(in), buf.String())
+	}
+
+	var buf bytes.Buffer
+	_, err := crockford.DecodeTo(crockford.Upper, &buf, "000")
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+	_, err = crockford.DecodeTo(crockford.Upper, &buf, "0*")
+	be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+
+	n, err := crockford.DecodeTo(crockford.Upper, &shortWriter{100}, crockford.Upper.EncodeToString(big))
+	be.True(t, err == io.ErrShortWrite)
+	be.Equal(t, 100, n)
+}
+
+func TestValidateGrouped(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		group int
+		ok    bool
+	}{
+		{"", 4, true},
+		{"A", 4, true},
+		{"ABCD", 4, true},
+		{"ABCD-EFGH-JK", 4, true},
+		{"abcd-efgh-jkmn", 4, true},
+		{"ABCD-EFGH-", 4, false},
+		{"-ABCD", 4, false},
+		{"ABC-DEFG", 4, false},
+		{"ABCDE-FGH", 4, false},
+		{"ABCD--EFGH", 4, false},
+		{"ABCD EFGH", 4, false},
+		{"AB*D-EFGH", 4, false},
+		{"A-B-C", 1, true},
+		{"AB-C", 1, false},
+	} {
+		err := crockford.ValidateGrouped(tc.in, tc.group, '-')
+		if tc.ok {
+			be.NilErr(t, err)
+		} else {
+			be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+		}
+	}
+	be.NilErr(t, crockford.ValidateGrouped("ABCD EFGH", 4, ' '))
+	for _, in := range []string{"", "1", "12", "1234567", "123456789abcdefg"} {
+		be.NilErr(t, crockford.ValidateGrouped(crockford.Partition(in, 3), 3, '-'))
+	}
+}
@@ -0,0 +1,3 @@
+package crockford
+
+var UniformIndex = uniformIndex
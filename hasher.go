@@ -0,0 +1,96 @@
+package crockford
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+)
+
+// Hasher encodes the digests of repeated inputs with one hash.Hash,
+// instead of allocating a new hash and digest for each input.
+// It is not faster than a one-shot function such as AppendMD5.
+// A Hasher is not safe for concurrent use.
+type Hasher struct {
+	e   *base32.Encoding
+	h   hash.Hash
+	sum []byte
+	dst []byte
+}
+
+// NewHasher returns a Hasher that encodes digests of h with e.
+func NewHasher(e *base32.Encoding, h hash.Hash) *Hasher {
+	return &Hasher{
+		e:   e,
+		h:   h,
+		sum: make([]byte, 0, h.Size()),
+		dst: make([]byte, 0, e.EncodedLen(h.Size())),
+	}
+}
+
+// Encode resets the hash, hashes src, and returns the encoded digest.
+func (hr *Hasher) Encode(src []byte) string {
+	hr.h.Reset()
+	hr.h.Write(src)
+	hr.sum = hr.h.Sum(hr.sum[:0])
+	hr.dst = Append(hr.e, hr.dst[:0], hr.sum)
+	return string(hr.dst)
+}
+
+// AppendSaltedHash appends onto dst the encoded digest from h
+// of salt followed by src. The same salt and src always give the same ID,
+// while without the salt, the ID cannot be matched to a guessed src.
+// The salt is hashed as is, with no length prefix, so use salts of
+// a fixed length to keep salt and src from running together,
+// and keep the salt secret if IDs must not be guessable.
+// For a keyed authentication code, use crypto/hmac instead.
+func AppendSaltedHash(e *base32.Encoding, h func() hash.Hash, salt, src []byte, dst []byte) []byte {
+	hh := h()
+	hh.Write(salt)
+	hh.Write(src)
+	var buf [64]byte
+	return Append(e, dst, hh.Sum(buf[:0]))
+}
+
+// AppendHash32 resets h, writes src into it, and appends onto dst
+// the encoding of its 4 byte sum, which is always 7 symbols.
+// It suits non-cryptographic fingerprints such as hash/fnv or hash/crc32.
+func AppendHash32(e *base32.Encoding, h hash.Hash32, src []byte, dst []byte) []byte {
+	h.Reset()
+	h.Write(src)
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], h.Sum32())
+	return Append(e, dst, buf[:])
+}
+
+// AppendHash64 is AppendHash32 for an 8 byte sum,
+// which is always 13 symbols.
+func AppendHash64(e *base32.Encoding, h hash.Hash64, src []byte, dst []byte) []byte {
+	h.Reset()
+	h.Write(src)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], h.Sum64())
+	return Append(e, dst, buf[:])
+}
+
+// Slug returns a short deterministic name for src, like an abbreviated
+// git hash. It encodes the SHA-256 hash of src and tries its prefixes
+// in order of length, starting from minLen symbols and growing by one
+// symbol each time exists reports the prefix as taken.
+// It returns an error if every prefix up to the full LenSHA256 symbols is taken.
+// It panics unless 1 <= minLen <= LenSHA256.
+func Slug(e *base32.Encoding, src []byte, minLen int, exists func(string) bool) (string, error) {
+	if minLen < 1 || minLen > LenSHA256 {
+		panic("invalid length")
+	}
+	sum := sha256.Sum256(src)
+	var buf [LenSHA256]byte
+	full := string(Append(e, buf[:0], sum[:]))
+	for n := minLen; n <= len(full); n++ {
+		if slug := full[:n]; !exists(slug) {
+			return slug, nil
+		}
+	}
+	return "", fmt.Errorf("crockford: no unused slug for %s", full)
+}
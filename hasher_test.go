@@ -0,0 +1,124 @@
+package crockford_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func TestHasher(t *testing.T) {
+	h := crockford.NewHasher(crockford.Lower, md5.New())
+	for _, in := range []string{"", "Hello, World!", "", "hello"} {
+		be.Equal(t, crockford.MD5(crockford.Lower, []byte(in)), h.Encode([]byte(in)))
+	}
+
+	h = crockford.NewHasher(crockford.Upper, sha256.New())
+	sum := sha256.Sum256([]byte("hello"))
+	be.Equal(t, crockford.Upper.EncodeToString(sum[:]), h.Encode([]byte("hello")))
+
+	src := []byte("Hello, World!")
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = h.Encode(src)
+	})
+	be.Equal(t, 1, allocs)
+}
+
+func BenchmarkHasher(b *testing.B) {
+	src := []byte("Hello, World!")
+	b.Run("hasher", func(b *testing.B) {
+		b.ReportAllocs()
+		h := crockford.NewHasher(crockford.Lower, md5.New())
+		for i := 0; i < b.N; i++ {
+			_ = h.Encode(src)
+		}
+	})
+	b.Run("md5", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = string(crockford.AppendMD5(crockford.Lower, nil, src))
+		}
+	})
+	b.Run("hasher-sha256", func(b *testing.B) {
+		b.ReportAllocs()
+		h := crockford.NewHasher(crockford.Lower, sha256.New())
+		for i := 0; i < b.N; i++ {
+			_ = h.Encode(src)
+		}
+	})
+	b.Run("sha256-new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = string(crockford.AppendSaltedHash(crockford.Lower, sha256.New, nil, src, nil))
+		}
+	})
+}
+
+func TestAppendSaltedHash(t *testing.T) {
+	src := []byte("hello")
+	a := crockford.AppendSaltedHash(crockford.Upper, sha256.New, []byte("salt-one"), src, []byte("x"))
+	be.Equal(t, byte('x'), a[0])
+	be.Equal(t, 1+crockford.LenSHA256, len(a))
+	b := crockford.AppendSaltedHash(crockford.Upper, sha256.New, []byte("salt-two"), src, nil)
+	be.Unequal(t, string(a[1:]), string(b))
+	be.Equal(t, string(a[1:]), string(crockford.AppendSaltedHash(crockford.Upper, sha256.New, []byte("salt-one"), src, nil)))
+
+	sum := sha256.Sum256([]byte("salt-onehello"))
+	be.Equal(t, crockford.Upper.EncodeToString(sum[:]), string(a[1:]))
+	// no salt is a plain digest
+	be.Equal(t, crockford.MD5(crockford.Lower, src), string(crockford.AppendSaltedHash(crockford.Lower, md5.New, nil, src, nil)))
+}
+
+func TestAppendHash32(t *testing.T) {
+	src := []byte("hello")
+	h32 := fnv.New32a()
+	h32.Write([]byte("leftover"))
+	got := crockford.AppendHash32(crockford.Upper, h32, src, []byte("x"))
+	be.Equal(t, 1+7, len(got))
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], 0x4f9f2cab) // fnv32a("hello")
+	be.Equal(t, "x"+crockford.Upper.EncodeToString(b[:]), string(got))
+
+	h64 := fnv.New64a()
+	got = crockford.AppendHash64(crockford.Lower, h64, src, nil)
+	be.Equal(t, 13, len(got))
+	got2 := crockford.AppendHash64(crockford.Lower, h64, src, nil)
+	be.Equal(t, string(got), string(got2))
+	v, err := crockford.DecodeUint64(crockford.Lower, string(got))
+	be.NilErr(t, err)
+	be.Equal(t, uint64(0xa430d84680aabd0b), v) // fnv64a("hello")
+
+	dst := make([]byte, 0, 13)
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = crockford.AppendHash64(crockford.Lower, h64, src, dst[:0])
+	})
+	be.Zero(t, allocs)
+}
+
+func TestSlug(t *testing.T) {
+	sum := sha256.Sum256([]byte("post title"))
+	full := crockford.Lower.EncodeToString(sum[:])
+	taken := map[string]bool{}
+	exists := func(s string) bool { return taken[s] }
+
+	slug, err := crockford.Slug(crockford.Lower, []byte("post title"), 4, exists)
+	be.NilErr(t, err)
+	be.Equal(t, full[:4], slug)
+
+	// collisions force longer slugs
+	taken[full[:4]] = true
+	taken[full[:5]] = true
+	slug, err = crockford.Slug(crockford.Lower, []byte("post title"), 4, exists)
+	be.NilErr(t, err)
+	be.Equal(t, full[:6], slug)
+
+	_, err = crockford.Slug(crockford.Lower, []byte("post title"), 4, func(string) bool { return true })
+	be.Nonzero(t, err)
+	slug, err = crockford.Slug(crockford.Lower, []byte("post title"), crockford.LenSHA256, exists)
+	be.NilErr(t, err)
+	be.Equal(t, full, slug)
+}
@@ -0,0 +1,279 @@
+package crockford
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LenID is the length of an ID made of
+// a LenTime timestamp, LenRandom random symbols, and a checksum symbol.
+const LenID = LenTime + LenRandom + 1
+
+// SplitID parses an ID made of a LenTime timestamp, LenRandom random symbols,
+// and a trailing checksum symbol computed with Checksum over the
+// 5 time bytes and 5 random bytes.
+// The ID is normalized first. It returns an error if the normalized ID
+// is not LenID long or cannot be decoded. A bad checksum is reported
+// by checksumOK rather than as an error.
+func SplitID(e *base32.Encoding, s string) (t time.Time, random []byte, checksumOK bool, err error) {
+	b := AppendNormalizedFor(nil, []byte(s), decodeAlphabet(e))
+	if len(b) != LenID {
+		return t, nil, false, fmt.Errorf("%w %d symbols, want %d", ErrInvalidLength, len(b), LenID)
+	}
+	var body [10]byte
+	if _, err = e.Decode(body[:], b[:LenTime+LenRandom]); err != nil {
+		return t, nil, false, wrapErr(err)
+	}
+	t = decodeTime(body[:5])
+	random = append([]byte(nil), body[5:]...)
+	checksumOK = Checksum(body[:], isUpper(e)) == b[LenID-1]
+	return t, random, checksumOK, nil
+}
+
+// AppendTimeRandom appends onto dst a LenTime timestamp as by AppendTime
+// followed by the encoding of randomBytes bytes from crypto/rand.
+// The result has LenTime + (8*randomBytes+4)/5 symbols,
+// e.g. 16 symbols for 5 random bytes or 24 for 10.
+// It panics if randomBytes is negative.
+func AppendTimeRandom(e *base32.Encoding, t time.Time, randomBytes int, dst []byte) []byte {
+	if randomBytes < 0 {
+		panic("invalid random length")
+	}
+	dst = AppendTime(e, t, dst)
+	src := make([]byte, randomBytes)
+	if _, err := rand.Read(src); err != nil {
+		panic(err)
+	}
+	return Append(e, dst, src)
+}
+
+// SplitTimeRandom parses an ID created by AppendTimeRandom with randomBytes bytes
+// of randomness into its timestamp and random bytes. s is normalized first.
+func SplitTimeRandom(e *base32.Encoding, s string, randomBytes int) (t time.Time, random []byte, err error) {
+	b := AppendNormalizedFor(nil, []byte(s), decodeAlphabet(e))
+	if want := LenTime + e.EncodedLen(randomBytes); len(b) != want {
+		return t, nil, fmt.Errorf("%w %d symbols, want %d", ErrInvalidLength, len(b), want)
+	}
+	var ts [5]byte
+	if _, err = e.Decode(ts[:], b[:LenTime]); err != nil {
+		return t, nil, wrapErr(err)
+	}
+	random = make([]byte, randomBytes)
+	n, err := e.Decode(random, b[LenTime:])
+	if err != nil {
+		return t, nil, wrapErr(err)
+	}
+	return decodeTime(ts[:]), random[:n], nil
+}
+
+// TimeRange returns the earliest and latest timestamps
+// of time-prefixed IDs, such as those created by AppendTimeRandom.
+// Each ID must start with a LenTime timestamp after normalization;
+// shorter IDs cause ErrInvalidLength.
+// If ids is empty, min and max are zero.
+func TimeRange(e *base32.Encoding, ids []string) (min, max time.Time, err error) {
+	alphabet := decodeAlphabet(e)
+	var buf []byte
+	for i, id := range ids {
+		buf = AppendNormalizedFor(buf[:0], []byte(id), alphabet)
+		if len(buf) < LenTime {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w %d symbols, want at least %d", ErrInvalidLength, len(buf), LenTime)
+		}
+		var ts [5]byte
+		if _, err = e.Decode(ts[:], buf[:LenTime]); err != nil {
+			return time.Time{}, time.Time{}, wrapErr(err)
+		}
+		t := decodeTime(ts[:])
+		if i == 0 || t.Before(min) {
+			min = t
+		}
+		if i == 0 || t.After(max) {
+			max = t
+		}
+	}
+	return min, max, nil
+}
+
+// AppendEncryptedTimeID encrypts a 16 byte block holding the big endian
+// Unix time in seconds and counter with block, such as an AES cipher,
+// and appends the LenBytes16 symbol encoding of the ciphertext onto dst.
+// The IDs are unpredictable and cannot be enumerated without the key,
+// but they do not sort by time. DecodeEncryptedTimeID recovers the time
+// and counter. It panics if the block size of block is not 16.
+func AppendEncryptedTimeID(block cipher.Block, e *base32.Encoding, t time.Time, counter uint64, dst []byte) []byte {
+	if block.BlockSize() != 16 {
+		panic("invalid block size")
+	}
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(t.Unix()))
+	binary.BigEndian.PutUint64(buf[8:], counter)
+	block.Encrypt(buf[:], buf[:])
+	return AppendBytes16(e, buf, dst)
+}
+
+// DecodeEncryptedTimeID decrypts an ID created by AppendEncryptedTimeID.
+// Decrypting with the wrong key gives meaningless results rather than an error.
+// It panics if the block size of block is not 16.
+func DecodeEncryptedTimeID(block cipher.Block, e *base32.Encoding, s string) (t time.Time, counter uint64, err error) {
+	if block.BlockSize() != 16 {
+		panic("invalid block size")
+	}
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return t, 0, err
+	}
+	if len(b) != 16 {
+		return t, 0, lenError(len(b), 16)
+	}
+	block.Decrypt(b, b)
+	t = time.Unix(int64(binary.BigEndian.Uint64(b[:8])), 0)
+	return t, binary.BigEndian.Uint64(b[8:]), nil
+}
+
+// LenDistributedID is the length returned by AppendDistributedID.
+const LenDistributedID = 20
+
+// AppendDistributedID appends onto dst LenDistributedID bytes with an ID
+// for nodes that generate IDs without coordinating, like a Snowflake ID.
+// The 12 encoded bytes are big endian: a 48-bit Unix millisecond timestamp,
+// the 16-bit nodeID, and the 32-bit seq. IDs sort by time, then node,
+// then sequence. Each node must keep seq unique within a millisecond,
+// which allows it 2**32 IDs per millisecond.
+// DecodeDistributedID reverses it.
+func AppendDistributedID(e *base32.Encoding, t time.Time, nodeID uint16, seq uint32, dst []byte) []byte {
+	var src [12]byte
+	ms := t.UnixMilli()
+	src[0] = byte(ms >> 40)
+	src[1] = byte(ms >> 32)
+	binary.BigEndian.PutUint32(src[2:6], uint32(ms))
+	binary.BigEndian.PutUint16(src[6:8], nodeID)
+	binary.BigEndian.PutUint32(src[8:], seq)
+	return appendN(e, LenDistributedID, dst, src[:])
+}
+
+// DecodeDistributedID decodes an ID created by AppendDistributedID.
+func DecodeDistributedID(e *base32.Encoding, s string) (t time.Time, nodeID uint16, seq uint32, err error) {
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return time.Time{}, 0, 0, err
+	}
+	if len(b) != 12 {
+		return time.Time{}, 0, 0, lenError(len(b), 12)
+	}
+	ms := int64(b[0])<<40 | int64(b[1])<<32 | int64(binary.BigEndian.Uint32(b[2:6]))
+	return time.UnixMilli(ms), binary.BigEndian.Uint16(b[6:8]), binary.BigEndian.Uint32(b[8:]), nil
+}
+
+// Format identifies an ID layout for MatchesFormat.
+type Format int
+
+// ID layouts
+const (
+	// FormatULID is a ULID, as read by ParseULIDStruct.
+	FormatULID Format = iota
+	// FormatUUID is LenUUID symbols encoding 16 bytes, as from AppendUUIDString.
+	FormatUUID
+	// FormatTimeRandomChecksum is a LenID ID with a valid checksum, as read by SplitID.
+	FormatTimeRandomChecksum
+)
+
+// MatchesFormat reports whether s, after normalization, is an ID
+// of the given layout, with the right length, decodable symbols,
+// and a valid checksum if the layout has one.
+// It reports false for an unknown layout.
+// Use IsCanonical to also reject IDs that need normalizing.
+func MatchesFormat(s string, layout Format) bool {
+	switch layout {
+	case FormatULID:
+		_, err := ParseULIDStruct(Upper, s)
+		return err == nil
+	case FormatUUID:
+		b, err := DecodeString(Upper, s)
+		return err == nil && len(b) == 16
+	case FormatTimeRandomChecksum:
+		_, _, ok, err := SplitID(Upper, s)
+		return err == nil && ok
+	}
+	return false
+}
+
+// SplitPrefix splits a prefixed ID such as "user_0123456789ABCDEF"
+// on the last occurrence of sep. If sep is not present,
+// prefix is empty and body is all of s.
+func SplitPrefix(s string, sep byte) (prefix, body string) {
+	i := strings.LastIndexByte(s, sep)
+	if i < 0 {
+		return "", s
+	}
+	return s[:i], s[i+1:]
+}
+
+// DecodeWithPrefix splits s with SplitPrefix and decodes the body with DecodeString.
+func DecodeWithPrefix(e *base32.Encoding, s string, sep byte) (prefix string, body []byte, err error) {
+	prefix, rest := SplitPrefix(s, sep)
+	body, err = DecodeString(e, rest)
+	if err != nil {
+		return prefix, nil, err
+	}
+	return prefix, body, nil
+}
+
+// IsSorted reports whether the normalized forms of ids are in
+// non-decreasing lexicographic order. For IDs that start with
+// a timestamp from AppendTime, this is chronological order.
+func IsSorted(ids []string) bool {
+	var prev, cur []byte
+	for i, id := range ids {
+		cur = AppendNormalized(cur[:0], []byte(id))
+		if i > 0 && string(cur) < string(prev) {
+			return false
+		}
+		prev, cur = cur, prev
+	}
+	return true
+}
+
+// Sort sorts ids in place by their normalized forms, so that IDs
+// entered in mixed case or with hyphens still sort consistently.
+// For IDs that start with a timestamp from AppendTime,
+// this is chronological order. The ids themselves are not modified.
+func Sort(ids []string) {
+	sort.Sort(newByNormalized(ids))
+}
+
+// SortStable is Sort, but keeps IDs with equal normalized forms
+// in their original order.
+func SortStable(ids []string) {
+	sort.Stable(newByNormalized(ids))
+}
+
+// byNormalized sorts ids by keys, their normalized forms
+type byNormalized struct {
+	ids, keys []string
+}
+
+func newByNormalized(ids []string) byNormalized {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = Normalized(id)
+	}
+	return byNormalized{ids, keys}
+}
+
+func (b byNormalized) Len() int           { return len(b.ids) }
+func (b byNormalized) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byNormalized) Swap(i, j int) {
+	b.ids[i], b.ids[j] = b.ids[j], b.ids[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// decodeTime reverses the 40-bit encoding of AppendTime
+func decodeTime(src []byte) time.Time {
+	return time.Unix(time40(src), 0)
+}
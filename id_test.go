@@ -0,0 +1,267 @@
+package crockford_test
+
+import (
+	"crypto/aes"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func TestSplitID(t *testing.T) {
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	random := []byte("\x01\x02\x03\x04\x05")
+	body := append([]byte{0x00, 0x5e, 0x0b, 0xe1, 0x00}, random...)
+	id := crockford.AppendTime(crockford.Upper, when, nil)
+	id = crockford.Append(crockford.Upper, id, random)
+	id = append(id, crockford.Checksum(body, true))
+	be.Equal(t, crockford.LenID, len(id))
+
+	for _, s := range []string{string(id), crockford.ToLower(string(id)), crockford.Partition(string(id), 4)} {
+		gotT, gotR, ok, err := crockford.SplitID(crockford.Upper, s)
+		be.NilErr(t, err)
+		be.True(t, ok)
+		be.True(t, when.Equal(gotT))
+		be.Equal(t, string(random), string(gotR))
+	}
+
+	// wrong checksum
+	bad := append([]byte(nil), id...)
+	bad[len(bad)-1] = '*'
+	if bad[len(bad)-1] == id[len(id)-1] {
+		bad[len(bad)-1] = '~'
+	}
+	_, _, ok, err := crockford.SplitID(crockford.Upper, string(bad))
+	be.NilErr(t, err)
+	be.False(t, ok)
+
+	// wrong lengths
+	for _, s := range []string{"", string(id[:len(id)-1]), string(id) + "0"} {
+		_, _, _, err = crockford.SplitID(crockford.Upper, s)
+		be.Nonzero(t, err)
+	}
+}
+
+func TestSplitPrefix(t *testing.T) {
+	for _, tc := range []struct{ in, prefix, body string }{
+		{"", "", ""},
+		{"D1JPRV3F", "", "D1JPRV3F"},
+		{"user_D1JPRV3F", "user", "D1JPRV3F"},
+		{"org_user_D1JPRV3F", "org_user", "D1JPRV3F"},
+		{"user_", "user", ""},
+		{"_D1JPRV3F", "", "D1JPRV3F"},
+	} {
+		prefix, body := crockford.SplitPrefix(tc.in, '_')
+		be.Equal(t, tc.prefix, prefix)
+		be.Equal(t, tc.body, body)
+	}
+}
+
+func TestDecodeWithPrefix(t *testing.T) {
+	prefix, body, err := crockford.DecodeWithPrefix(crockford.Upper, "greeting_d1jp-rv3f-41vp-ywkc-cg", '_')
+	be.NilErr(t, err)
+	be.Equal(t, "greeting", prefix)
+	be.Equal(t, "hello world", string(body))
+
+	prefix, body, err = crockford.DecodeWithPrefix(crockford.Upper, "ZZZZZZZZ", '_')
+	be.NilErr(t, err)
+	be.Equal(t, "", prefix)
+	be.Equal(t, "\xff\xff\xff\xff\xff", string(body))
+
+	prefix, _, err = crockford.DecodeWithPrefix(crockford.Upper, "user_ZZ*", '_')
+	be.Nonzero(t, err)
+	be.Equal(t, "user", prefix)
+}
+
+func TestIsSorted(t *testing.T) {
+	var ids []string
+	for _, year := range []int{1999, 2000, 2020, 2020, 2038, 2100} {
+		when := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		ids = append(ids, crockford.Time(crockford.Lower, when)+crockford.Random(crockford.Lower))
+	}
+	ids[2], ids[3] = "01f0qr80zzzzzzzz", "01F0-QR80-ZZZZ-ZZZZ"
+	be.True(t, crockford.IsSorted(nil))
+	be.True(t, crockford.IsSorted(ids[:1]))
+	be.True(t, crockford.IsSorted(ids))
+
+	ids[1], ids[4] = ids[4], ids[1]
+	be.False(t, crockford.IsSorted(ids))
+
+	// raw comparison would put uppercase first
+	be.True(t, crockford.IsSorted([]string{"A0", "b0", "C0"}))
+	be.False(t, crockford.IsSorted([]string{"b0", "A0"}))
+}
+
+func TestSort(t *testing.T) {
+	ids := []string{"c0", "B0", "a-0", "01F0QR80", "00W6-VV20", "01f0-qr7z"}
+	sort.Strings(ids)
+	be.False(t, crockford.IsSorted(ids))
+
+	crockford.Sort(ids)
+	be.True(t, crockford.IsSorted(ids))
+	be.AllEqual(t, []string{"00W6-VV20", "01f0-qr7z", "01F0QR80", "a-0", "B0", "c0"}, ids)
+
+	ids = []string{"b0", "A-0", "B0", "a0", "b-0"}
+	crockford.SortStable(ids)
+	be.AllEqual(t, []string{"A-0", "a0", "b0", "B0", "b-0"}, ids)
+
+	crockford.Sort(nil)
+}
+
+func TestAppendTimeRandom(t *testing.T) {
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, n := range []int{0, 1, 5, 10, 16} {
+		id := crockford.AppendTimeRandom(crockford.Lower, when, n, []byte("x"))
+		be.Equal(t, "x", string(id[:1]))
+		id = id[1:]
+		be.Equal(t, crockford.LenTime+(8*n+4)/5, len(id))
+		be.Equal(t, crockford.Time(crockford.Lower, when), string(id[:crockford.LenTime]))
+
+		gotT, random, err := crockford.SplitTimeRandom(crockford.Lower, string(id), n)
+		be.NilErr(t, err)
+		be.True(t, when.Equal(gotT))
+		be.Equal(t, n, len(random))
+		be.Equal(t, string(id[crockford.LenTime:]), crockford.Lower.EncodeToString(random))
+
+		_, _, err = crockford.SplitTimeRandom(crockford.Lower, string(id), n+1)
+		be.Nonzero(t, err)
+	}
+	a := crockford.AppendTimeRandom(crockford.Upper, when, 10, nil)
+	b := crockford.AppendTimeRandom(crockford.Upper, when, 10, nil)
+	be.Unequal(t, string(a), string(b))
+}
+
+func TestTimeRange(t *testing.T) {
+	t1 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	t3 := time.Date(2038, 1, 19, 3, 14, 7, 0, time.UTC)
+	ids := []string{
+		string(crockford.AppendTimeRandom(crockford.Upper, t2, 5, nil)),
+		crockford.Partition(string(crockford.AppendTimeRandom(crockford.Lower, t3, 10, nil)), 4),
+		crockford.Time(crockford.Upper, t1),
+		string(crockford.AppendTimeRandom(crockford.Upper, t2, 0, nil)),
+	}
+	min, max, err := crockford.TimeRange(crockford.Upper, ids)
+	be.NilErr(t, err)
+	be.True(t, t1.Equal(min))
+	be.True(t, t3.Equal(max))
+
+	min, max, err = crockford.TimeRange(crockford.Upper, ids[:1])
+	be.NilErr(t, err)
+	be.True(t, t2.Equal(min))
+	be.True(t, t2.Equal(max))
+
+	min, max, err = crockford.TimeRange(crockford.Upper, nil)
+	be.NilErr(t, err)
+	be.True(t, min.IsZero())
+	be.True(t, max.IsZero())
+
+	_, _, err = crockford.TimeRange(crockford.Upper, append(ids, "01F0QR8"))
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+}
+
+func TestAppendEncryptedTimeID(t *testing.T) {
+	block, err := aes.NewCipher([]byte("0123456789abcdef"))
+	be.NilErr(t, err)
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	seen := make(map[string]bool)
+	for counter := uint64(0); counter < 100; counter++ {
+		id := crockford.AppendEncryptedTimeID(block, crockford.Upper, when, counter, nil)
+		be.Equal(t, crockford.LenBytes16, len(id))
+		be.False(t, seen[string(id)])
+		seen[string(id)] = true
+
+		gotT, gotC, err := crockford.DecodeEncryptedTimeID(block, crockford.Lower, crockford.ToLower(string(id)))
+		be.NilErr(t, err)
+		be.True(t, when.Equal(gotT))
+		be.Equal(t, counter, gotC)
+	}
+
+	other, err := aes.NewCipher([]byte("fedcba9876543210"))
+	be.NilErr(t, err)
+	id := crockford.AppendEncryptedTimeID(block, crockford.Upper, when, 1, nil)
+	gotT, _, err := crockford.DecodeEncryptedTimeID(other, crockford.Upper, string(id))
+	be.NilErr(t, err)
+	be.False(t, when.Equal(gotT))
+
+	_, _, err = crockford.DecodeEncryptedTimeID(block, crockford.Upper, "00000000")
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+}
+
+func TestAppendDistributedID(t *testing.T) {
+	when := time.Date(2020, 1, 1, 0, 0, 0, 123e6, time.UTC)
+	for _, tc := range []struct {
+		node uint16
+		seq  uint32
+	}{
+		{0, 0}, {1, 1}, {0xffff, 0xffffffff}, {42, 1 << 31},
+	} {
+		id := crockford.AppendDistributedID(crockford.Upper, when, tc.node, tc.seq, []byte("x"))
+		be.Equal(t, 1+crockford.LenDistributedID, len(id))
+		got, node, seq, err := crockford.DecodeDistributedID(crockford.Lower, crockford.ToLower(string(id[1:])))
+		be.NilErr(t, err)
+		be.True(t, when.Equal(got))
+		be.Equal(t, tc.node, node)
+		be.Equal(t, tc.seq, seq)
+	}
+	max := crockford.MaxTime(crockford.TimeMillis48)
+	got, _, _, err := crockford.DecodeDistributedID(crockford.Upper, string(crockford.AppendDistributedID(crockford.Upper, max, 1, 2, nil)))
+	be.NilErr(t, err)
+	be.True(t, max.Equal(got))
+
+	// sorted by time, then node, then sequence
+	ids := []string{
+		string(crockford.AppendDistributedID(crockford.Upper, when, 2, 0, nil)),
+		string(crockford.AppendDistributedID(crockford.Upper, when, 2, 1, nil)),
+		string(crockford.AppendDistributedID(crockford.Upper, when, 3, 0, nil)),
+		string(crockford.AppendDistributedID(crockford.Upper, when.Add(time.Millisecond), 0, 0, nil)),
+	}
+	be.True(t, crockford.IsSorted(ids))
+
+	_, _, _, err = crockford.DecodeDistributedID(crockford.Upper, ids[0][:16])
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+}
+
+func TestMatchesFormat(t *testing.T) {
+	uuid, err := crockford.AppendUUIDString(crockford.Lower, "123e4567-e89b-12d3-a456-426614174000", nil)
+	be.NilErr(t, err)
+	id := crockford.AppendTime(crockford.Upper, time.Now(), nil)
+	id = crockford.AppendRandom(crockford.Upper, id)
+	body, err := crockford.DecodeString(crockford.Upper, string(id))
+	be.NilErr(t, err)
+	ck := crockford.Checksum(body, true)
+	id = append(id, ck)
+	bad := string(id[:len(id)-1]) + "*"
+	if ck == '*' {
+		bad = string(id[:len(id)-1]) + "~"
+	}
+
+	for _, tc := range []struct {
+		s      string
+		layout crockford.Format
+		want   bool
+	}{
+		{"01ARYZ6S41TSV4RRFFQ69G5FAV", crockford.FormatULID, true},
+		{"01aryz6s41-tsv4rrffq69g5fav", crockford.FormatULID, true},
+		{"81ARYZ6S41TSV4RRFFQ69G5FAV", crockford.FormatULID, false},
+		{"01ARYZ6S41TSV4RRFFQ69G5FA", crockford.FormatULID, false},
+		{string(uuid), crockford.FormatUUID, true},
+		{crockford.Partition(string(uuid), 4), crockford.FormatUUID, true},
+		{string(uuid[:25]), crockford.FormatUUID, false},
+		{string(uuid) + "0", crockford.FormatUUID, false},
+		{string(uuid[:25]) + "*", crockford.FormatUUID, false},
+		{string(id), crockford.FormatTimeRandomChecksum, true},
+		{crockford.ToLower(string(id)), crockford.FormatTimeRandomChecksum, true},
+		{bad, crockford.FormatTimeRandomChecksum, false},
+		{string(id[:16]), crockford.FormatTimeRandomChecksum, false},
+		{string(id), crockford.FormatUUID, false},
+		{string(uuid), crockford.FormatTimeRandomChecksum, false},
+		{string(id), crockford.Format(-1), false},
+	} {
+		be.Equal(t, tc.want, crockford.MatchesFormat(tc.s, tc.layout))
+	}
+}
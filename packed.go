@@ -0,0 +1,68 @@
+package crockford
+
+import (
+	"encoding/base32"
+)
+
+// AppendPacked bit-packs fields into a big endian buffer using the matching
+// bit widths, and appends the encoded buffer onto dst.
+// If the widths do not sum to a multiple of 8, the buffer is padded
+// with zero bits at the end.
+// It panics if fields and widths differ in length, if a width is not
+// between 1 and 64, or if a field does not fit in its width.
+func AppendPacked(e *base32.Encoding, fields []uint64, widths []int, dst []byte) []byte {
+	if len(fields) != len(widths) {
+		panic("mismatched fields and widths")
+	}
+	buf := make([]byte, packedLen(widths))
+	pos := 0
+	for i, v := range fields {
+		w := widths[i]
+		if w < 64 && v>>w != 0 {
+			panic("field overflows width")
+		}
+		for bit := w - 1; bit >= 0; bit-- {
+			if v>>bit&1 == 1 {
+				buf[pos/8] |= 0x80 >> (pos % 8)
+			}
+			pos++
+		}
+	}
+	return Append(e, dst, buf)
+}
+
+// DecodePacked decodes s and unpacks fields with the given bit widths,
+// reversing AppendPacked. It panics if a width is not between 1 and 64.
+func DecodePacked(e *base32.Encoding, s string, widths []int) ([]uint64, error) {
+	n := packedLen(widths)
+	buf, err := DecodeString(e, s)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) != n {
+		return nil, lenError(len(buf), n)
+	}
+	fields := make([]uint64, len(widths))
+	pos := 0
+	for i, w := range widths {
+		var v uint64
+		for j := 0; j < w; j++ {
+			v = v<<1 | uint64(buf[pos/8]>>(7-pos%8)&1)
+			pos++
+		}
+		fields[i] = v
+	}
+	return fields, nil
+}
+
+// packedLen returns the number of bytes needed to hold widths bits
+func packedLen(widths []int) int {
+	bits := 0
+	for _, w := range widths {
+		if w < 1 || w > 64 {
+			panic("invalid width")
+		}
+		bits += w
+	}
+	return (bits + 7) / 8
+}
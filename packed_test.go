@@ -0,0 +1,51 @@
+package crockford_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func TestAppendPacked(t *testing.T) {
+	for _, tc := range []struct {
+		fields []uint64
+		widths []int
+		want   string
+	}{
+		{nil, nil, ""},
+		{[]uint64{0xff}, []int{8}, "ZW"},
+		{[]uint64{0xab, 0xcdef}, []int{8, 16}, crockford.Upper.EncodeToString([]byte{0xab, 0xcd, 0xef})},
+		{[]uint64{1, 0}, []int{1, 7}, crockford.Upper.EncodeToString([]byte{0x80})},
+		{[]uint64{7, 1 << 39, 3}, []int{3, 40, 5}, ""},
+		{[]uint64{math.MaxUint64, 1}, []int{64, 1}, ""},
+		{[]uint64{5, 3, 1}, []int{3, 2, 1}, crockford.Upper.EncodeToString([]byte{0b101_11_1_00})},
+	} {
+		s := string(crockford.AppendPacked(crockford.Upper, tc.fields, tc.widths, nil))
+		if tc.want != "" {
+			be.Equal(t, tc.want, s)
+		}
+		got, err := crockford.DecodePacked(crockford.Upper, s, tc.widths)
+		be.NilErr(t, err)
+		be.Equal(t, len(tc.fields), len(got))
+		for i := range got {
+			be.Equal(t, tc.fields[i], got[i])
+		}
+	}
+
+	_, err := crockford.DecodePacked(crockford.Upper, "ZW", []int{8, 8})
+	be.Nonzero(t, err)
+
+	for _, f := range []func(){
+		func() { crockford.AppendPacked(crockford.Upper, []uint64{1}, nil, nil) },
+		func() { crockford.AppendPacked(crockford.Upper, []uint64{4}, []int{2}, nil) },
+		func() { crockford.AppendPacked(crockford.Upper, []uint64{0}, []int{0}, nil) },
+		func() { crockford.AppendPacked(crockford.Upper, []uint64{0}, []int{65}, nil) },
+	} {
+		func() {
+			defer func() { be.Nonzero(t, recover()) }()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,138 @@
+package crockford
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LenSequence is the length returned by Sequencer.AppendID.
+const LenSequence = 13
+
+// MaxSequence is the number of IDs a Sequencer can create per millisecond.
+const MaxSequence = 1 << 16
+
+// Sequencer creates dense, sortable IDs for a single writer.
+// Each ID is a big endian 48-bit Unix millisecond timestamp followed by
+// a 16-bit counter that restarts at zero each new millisecond.
+// After MaxSequence IDs in one millisecond, or if the clock goes backwards,
+// the Sequencer continues from its last timestamp, so IDs never repeat
+// or go out of order.
+//
+// The zero value is ready to use. A Sequencer is safe for concurrent use.
+type Sequencer struct {
+	mu      sync.Mutex
+	started bool
+	ms      int64
+	seq     uint16
+}
+
+// AppendID appends onto dst LenSequence bytes with the next ID for t.
+func (s *Sequencer) AppendID(e *base32.Encoding, t time.Time, dst []byte) []byte {
+	s.mu.Lock()
+	ms, seq := s.next(t.UnixMilli())
+	s.mu.Unlock()
+	return appendSequence(e, ms, seq, dst)
+}
+
+// AppendN appends onto dst n consecutive IDs for t, each LenSequence bytes,
+// acquiring the lock only once. Counter overflow is handled as by AppendID.
+func (s *Sequencer) AppendN(e *base32.Encoding, t time.Time, n int, dst []byte) []byte {
+	dst = grow(dst, n*LenSequence)
+	ms := t.UnixMilli()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		idMS, seq := s.next(ms)
+		dst = appendSequence(e, idMS, seq, dst)
+	}
+	return dst
+}
+
+// next returns the timestamp and counter to use for ms. s.mu must be held.
+func (s *Sequencer) next(ms int64) (int64, uint16) {
+	switch {
+	case !s.started || ms > s.ms:
+		s.started = true
+		s.ms, s.seq = ms, 0
+	case s.seq == MaxSequence-1:
+		s.ms, s.seq = s.ms+1, 0
+	default:
+		s.seq++
+	}
+	return s.ms, s.seq
+}
+
+// AtomicSequencer creates the same IDs as Sequencer, but uses
+// compare and swap on a single word instead of a mutex,
+// which contends less when many goroutines create IDs at once.
+// Times before the Unix epoch are treated as the epoch.
+//
+// The zero value is ready to use. An AtomicSequencer is safe for concurrent use.
+type AtomicSequencer struct {
+	// state is 1 + the last timestamp<<16 | counter, or 0 if unused.
+	// It comes first to keep it 64-bit aligned on 32-bit platforms.
+	state uint64
+}
+
+// AppendID appends onto dst LenSequence bytes with the next ID for t.
+func (s *AtomicSequencer) AppendID(e *base32.Encoding, t time.Time, dst []byte) []byte {
+	ms, seq := s.next(t.UnixMilli())
+	return appendSequence(e, ms, seq, dst)
+}
+
+func (s *AtomicSequencer) next(ms int64) (int64, uint16) {
+	if ms < 0 {
+		ms = 0
+	}
+	for {
+		old := atomic.LoadUint64(&s.state)
+		packed := uint64(ms) << 16
+		if old != 0 && int64((old-1)>>16) >= ms {
+			// Increment the last counter. On overflow
+			// the carry moves on to the next millisecond.
+			packed = old
+		}
+		if atomic.CompareAndSwapUint64(&s.state, old, packed+1) {
+			return int64(packed >> 16), uint16(packed)
+		}
+	}
+}
+
+// NextFollows reports whether b is the ID directly after a in the layout
+// of Sequencer, read as a 64-bit number of the timestamp and counter,
+// for detecting dropped IDs. The counter carries over into the
+// next millisecond as on overflow, but when the clock moves on,
+// a Sequencer restarts its counter and skips ahead, so such pairs
+// report false even though no ID was dropped.
+// It reports false if either ID does not decode to 8 bytes.
+func NextFollows(e *base32.Encoding, a, b string) bool {
+	x, okA := decodeSequenceValue(e, a)
+	y, okB := decodeSequenceValue(e, b)
+	return okA && okB && x != math.MaxUint64 && x+1 == y
+}
+
+func decodeSequenceValue(e *base32.Encoding, s string) (uint64, bool) {
+	var buf [8]byte
+	b, err := AppendDecoded(e, buf[:0], []byte(s))
+	if err != nil || len(b) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(b), true
+}
+
+func appendSequence(e *base32.Encoding, ms int64, seq uint16, dst []byte) []byte {
+	var src [8]byte
+	src[0] = byte(ms >> 40)
+	src[1] = byte(ms >> 32)
+	src[2] = byte(ms >> 24)
+	src[3] = byte(ms >> 16)
+	src[4] = byte(ms >> 8)
+	src[5] = byte(ms)
+	src[6] = byte(seq >> 8)
+	src[7] = byte(seq)
+	return appendN(e, LenSequence, dst, src[:])
+}
@@ -0,0 +1,222 @@
+package crockford_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func decodeSequence(t *testing.T, id []byte) (ms int64, seq int) {
+	t.Helper()
+	b, err := crockford.DecodeString(crockford.Upper, string(id))
+	be.NilErr(t, err)
+	be.Equal(t, 8, len(b))
+	for _, c := range b[:6] {
+		ms = ms<<8 | int64(c)
+	}
+	return ms, int(b[6])<<8 | int(b[7])
+}
+
+func TestSequencer(t *testing.T) {
+	var s crockford.Sequencer
+	start := time.UnixMilli(1_600_000_000_000)
+
+	id := s.AppendID(crockford.Upper, start, nil)
+	be.Equal(t, crockford.LenSequence, len(id))
+	ms, seq := decodeSequence(t, id)
+	be.Equal(t, start.UnixMilli(), ms)
+	be.Equal(t, 0, seq)
+
+	// counter within a millisecond, then reset
+	prev := string(id)
+	for i := 1; i < 5; i++ {
+		id = s.AppendID(crockford.Upper, start, nil)
+		be.True(t, prev < string(id))
+		prev = string(id)
+		_, seq = decodeSequence(t, id)
+		be.Equal(t, i, seq)
+	}
+	id = s.AppendID(crockford.Upper, start.Add(time.Millisecond), nil)
+	ms, seq = decodeSequence(t, id)
+	be.Equal(t, start.UnixMilli()+1, ms)
+	be.Equal(t, 0, seq)
+
+	// clock going backwards keeps counting
+	id = s.AppendID(crockford.Upper, start, nil)
+	ms, seq = decodeSequence(t, id)
+	be.Equal(t, start.UnixMilli()+1, ms)
+	be.Equal(t, 1, seq)
+
+	// overflow moves onto the next millisecond
+	var o crockford.Sequencer
+	for i := 0; i < crockford.MaxSequence; i++ {
+		id = o.AppendID(crockford.Upper, start, id[:0])
+	}
+	ms, seq = decodeSequence(t, id)
+	be.Equal(t, start.UnixMilli(), ms)
+	be.Equal(t, crockford.MaxSequence-1, seq)
+	id = o.AppendID(crockford.Upper, start, id[:0])
+	ms, seq = decodeSequence(t, id)
+	be.Equal(t, start.UnixMilli()+1, ms)
+	be.Equal(t, 0, seq)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		id = s.AppendID(crockford.Upper, start, id[:0])
+	})
+	be.Zero(t, allocs)
+}
+
+func TestSequencerConcurrent(t *testing.T) {
+	var s crockford.Sequencer
+	now := time.Now()
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				id := string(s.AppendID(crockford.Lower, now, nil))
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	be.Equal(t, 8000, len(seen))
+}
+
+func TestSequencerAppendN(t *testing.T) {
+	var s crockford.Sequencer
+	start := time.UnixMilli(1_600_000_000_000)
+	first := s.AppendID(crockford.Upper, start, nil)
+
+	const n = crockford.MaxSequence + 10
+	batch := s.AppendN(crockford.Upper, start, n, []byte("x"))
+	be.Equal(t, "x", string(batch[:1]))
+	batch = batch[1:]
+	be.Equal(t, n*crockford.LenSequence, len(batch))
+
+	prev := string(first)
+	seen := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		id := string(batch[i*crockford.LenSequence : (i+1)*crockford.LenSequence])
+		be.True(t, prev < id)
+		be.False(t, seen[id])
+		seen[id] = true
+		prev = id
+	}
+	// rolled over into the next millisecond
+	ms, seq := decodeSequence(t, []byte(prev))
+	be.Equal(t, start.UnixMilli()+1, ms)
+	be.Equal(t, 10, seq)
+
+	next := s.AppendID(crockford.Upper, start, nil)
+	be.True(t, prev < string(next))
+
+	be.Equal(t, "", string(s.AppendN(crockford.Upper, start, 0, nil)))
+}
+
+func TestAtomicSequencer(t *testing.T) {
+	var s crockford.Sequencer
+	var as crockford.AtomicSequencer
+	start := time.UnixMilli(1_600_000_000_000)
+	times := []time.Time{start, start, start.Add(time.Millisecond), start, start.Add(5 * time.Millisecond)}
+	for _, when := range times {
+		be.Equal(t, string(s.AppendID(crockford.Upper, when, nil)), string(as.AppendID(crockford.Upper, when, nil)))
+	}
+	// counter overflow moves on to the next millisecond
+	for i := 1; i < crockford.MaxSequence; i++ {
+		_ = as.AppendID(crockford.Upper, start, nil)
+	}
+	ms, seq := decodeSequence(t, as.AppendID(crockford.Upper, start, nil))
+	be.Equal(t, start.Add(6*time.Millisecond).UnixMilli(), ms)
+	be.Equal(t, 0, seq)
+
+	var zero crockford.AtomicSequencer
+	ms, seq = decodeSequence(t, zero.AppendID(crockford.Upper, time.UnixMilli(0), nil))
+	be.Equal(t, int64(0), ms)
+	be.Equal(t, 0, seq)
+	ms, seq = decodeSequence(t, zero.AppendID(crockford.Upper, time.UnixMilli(-5), nil))
+	be.Equal(t, int64(0), ms)
+	be.Equal(t, 1, seq)
+}
+
+func TestAtomicSequencerConcurrent(t *testing.T) {
+	var s crockford.AtomicSequencer
+	now := time.Now()
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10_000; j++ {
+				id := string(s.AppendID(crockford.Lower, now, nil))
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	be.Equal(t, 80_000, len(seen))
+}
+
+func BenchmarkSequencerParallel(b *testing.B) {
+	now := time.Now()
+	b.Run("Sequencer", func(b *testing.B) {
+		var s crockford.Sequencer
+		b.RunParallel(func(pb *testing.PB) {
+			dst := make([]byte, 0, crockford.LenSequence)
+			for pb.Next() {
+				dst = s.AppendID(crockford.Upper, now, dst[:0])
+			}
+		})
+	})
+	b.Run("AtomicSequencer", func(b *testing.B) {
+		var s crockford.AtomicSequencer
+		b.RunParallel(func(pb *testing.PB) {
+			dst := make([]byte, 0, crockford.LenSequence)
+			for pb.Next() {
+				dst = s.AppendID(crockford.Upper, now, dst[:0])
+			}
+		})
+	})
+}
+
+func TestNextFollows(t *testing.T) {
+	var s crockford.Sequencer
+	start := time.UnixMilli(1_600_000_000_000)
+	a := string(s.AppendID(crockford.Upper, start, nil))
+	b := string(s.AppendID(crockford.Upper, start, nil))
+	c := string(s.AppendID(crockford.Upper, start, nil))
+	be.True(t, crockford.NextFollows(crockford.Upper, a, b))
+	be.True(t, crockford.NextFollows(crockford.Lower, crockford.ToLower(b), crockford.Partition(c, 4)))
+	// gapped
+	be.False(t, crockford.NextFollows(crockford.Upper, a, c))
+	// out of order
+	be.False(t, crockford.NextFollows(crockford.Upper, b, a))
+	be.False(t, crockford.NextFollows(crockford.Upper, a, a))
+
+	// counter overflow carries into the next millisecond
+	var last string
+	for i := 3; i < crockford.MaxSequence; i++ {
+		last = string(s.AppendID(crockford.Upper, start, nil))
+	}
+	next := string(s.AppendID(crockford.Upper, start, nil))
+	ms, seq := decodeSequence(t, []byte(next))
+	be.Equal(t, start.UnixMilli()+1, ms)
+	be.Equal(t, 0, seq)
+	be.True(t, crockford.NextFollows(crockford.Upper, last, next))
+
+	be.False(t, crockford.NextFollows(crockford.Upper, "", a))
+	be.False(t, crockford.NextFollows(crockford.Upper, a, "ZZZZ"))
+	be.False(t, crockford.NextFollows(crockford.Upper, "ZZZZZZZZZZZZG", "0000000000000"))
+}
@@ -0,0 +1,132 @@
+package crockford_test
+
+import (
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+// Test vectors from https://www.crockford.com/base32.html
+
+func TestSpecSymbols(t *testing.T) {
+	for _, tc := range []struct {
+		value  int
+		encode byte
+		decode string
+	}{
+		{0, '0', "0Oo"},
+		{1, '1', "1IiLl"},
+		{2, '2', "2"},
+		{3, '3', "3"},
+		{4, '4', "4"},
+		{5, '5', "5"},
+		{6, '6', "6"},
+		{7, '7', "7"},
+		{8, '8', "8"},
+		{9, '9', "9"},
+		{10, 'A', "Aa"},
+		{11, 'B', "Bb"},
+		{12, 'C', "Cc"},
+		{13, 'D', "Dd"},
+		{14, 'E', "Ee"},
+		{15, 'F', "Ff"},
+		{16, 'G', "Gg"},
+		{17, 'H', "Hh"},
+		{18, 'J', "Jj"},
+		{19, 'K', "Kk"},
+		{20, 'M', "Mm"},
+		{21, 'N', "Nn"},
+		{22, 'P', "Pp"},
+		{23, 'Q', "Qq"},
+		{24, 'R', "Rr"},
+		{25, 'S', "Ss"},
+		{26, 'T', "Tt"},
+		{27, 'V', "Vv"},
+		{28, 'W', "Ww"},
+		{29, 'X', "Xx"},
+		{30, 'Y', "Yy"},
+		{31, 'Z', "Zz"},
+	} {
+		be.Equal(t, tc.encode, crockford.UppercaseAlphabet[tc.value])
+		be.Equal(t, string(tc.encode), string(crockford.AppendNumber(crockford.Upper, uint64(tc.value), nil)))
+		for _, c := range []byte(tc.decode) {
+			v, ok := crockford.SymbolValue(c)
+			be.True(t, ok)
+			be.Equal(t, tc.value, v)
+			be.Equal(t, string(tc.encode), crockford.Normalized(string(c)))
+		}
+	}
+}
+
+func TestSpecCheckSymbols(t *testing.T) {
+	for _, tc := range []struct {
+		value  int
+		encode byte
+		decode string
+	}{
+		{32, '*', "*"},
+		{33, '~', "~"},
+		{34, '$', "$"},
+		{35, '=', "="},
+		{36, 'U', "Uu"},
+	} {
+		be.Equal(t, tc.encode, crockford.UppercaseChecksum[tc.value])
+		for _, c := range []byte(tc.decode) {
+			be.Equal(t, string(tc.encode), crockford.Normalized(string(c)))
+			_, ok := crockford.SymbolValue(c)
+			be.False(t, ok)
+		}
+	}
+}
+
+func TestSpecNumbers(t *testing.T) {
+	for _, tc := range []struct {
+		value    uint64
+		encoded  string
+		checksum byte
+	}{
+		{0, "0", '0'},
+		{1, "1", '1'},
+		{31, "Z", 'Z'},
+		{32, "10", '*'},
+		{36, "14", 'U'},
+		{37, "15", '0'},
+		{1234, "16J", 'D'},
+		{1 << 40, "100000000", crockford.UppercaseChecksum[(1<<40)%37]},
+		{1<<64 - 1, "FZZZZZZZZZZZZ", crockford.UppercaseChecksum[(1<<64-1)%37]},
+	} {
+		be.Equal(t, tc.encoded, string(crockford.AppendNumber(crockford.Upper, tc.value, nil)))
+		be.Equal(t, crockford.ToLower(tc.encoded), string(crockford.AppendNumber(crockford.Lower, tc.value, nil)))
+
+		v, err := crockford.ValueBits(crockford.Upper, tc.encoded, 64)
+		be.NilErr(t, err)
+		be.Equal(t, tc.value, v)
+
+		c, err := crockford.ChecksumString(crockford.Upper, tc.encoded)
+		be.NilErr(t, err)
+		be.Equal(t, tc.checksum, c)
+	}
+}
+
+func TestSpecHyphens(t *testing.T) {
+	// Hyphens are ignored when decoding
+	v, err := crockford.ValueBits(crockford.Upper, "1-6-J", 64)
+	be.NilErr(t, err)
+	be.Equal(t, 1234, v)
+	be.Equal(t, "16J", crockford.Normalized("1-6-J"))
+	c, err := crockford.ChecksumString(crockford.Upper, "16-J")
+	be.NilErr(t, err)
+	be.Equal(t, 'D', c)
+}
+
+// The byte encoders pad at the end instead of zero-extending at the front,
+// so they agree with the specification only for multiples of 5 bytes.
+func TestSpecDeviation(t *testing.T) {
+	be.Equal(t, "04", crockford.Upper.EncodeToString([]byte{1}))
+	be.Equal(t, "1", string(crockford.AppendNumber(crockford.Upper, 1, nil)))
+	be.Equal(t, "0000000000000001", crockford.Upper.EncodeToString([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 1}))
+
+	// Checksum of whole bytes matches the numeric checksum of the same value
+	be.Equal(t, byte('D'), crockford.Checksum([]byte{0x04, 0xd2}, true))
+}
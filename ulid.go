@@ -0,0 +1,71 @@
+package crockford
+
+import (
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LenULID is the length of a ULID string
+const LenULID = 26
+
+// ParsedULID holds the components of a ULID.
+type ParsedULID struct {
+	Time    time.Time // millisecond precision
+	Entropy [10]byte
+}
+
+// ParseULIDStruct decodes a ULID, which is a 128-bit number
+// written as LenULID symbols following the numeric specification,
+// with a 48-bit Unix millisecond time followed by 80 bits of entropy.
+// s is normalized for the alphabet of e first.
+// It returns ErrInvalidLength unless s has LenULID symbols,
+// and ErrOverflow if the first symbol is greater than 7,
+// which would put the time beyond 48 bits.
+func ParseULIDStruct(e *base32.Encoding, s string) (ParsedULID, error) {
+	alphabet := decodeAlphabet(e)
+	var buf [LenULID]byte
+	b := buf[:0]
+	for i := 0; i < len(s); i++ {
+		if c := normFor(s[i], alphabet); c != 0 {
+			if len(b) == LenULID {
+				return ParsedULID{}, fmt.Errorf("%w: ULID longer than %d symbols", ErrInvalidLength, LenULID)
+			}
+			b = append(b, c)
+		}
+	}
+	if len(b) != LenULID {
+		return ParsedULID{}, fmt.Errorf("%w: ULID of %d symbols, want %d", ErrInvalidLength, len(b), LenULID)
+	}
+	var raw [16]byte
+	var acc uint
+	nbits, j := 0, 0
+	for i, c := range b {
+		v := strings.IndexByte(alphabet, c)
+		if v < 0 || v >= 32 {
+			return ParsedULID{}, fmt.Errorf("%w %q", ErrInvalidChar, c)
+		}
+		width := 5
+		if i == 0 {
+			if v > 7 {
+				return ParsedULID{}, ErrOverflow
+			}
+			width = 3
+		}
+		acc = acc<<width | uint(v)
+		nbits += width
+		if nbits >= 8 {
+			nbits -= 8
+			raw[j] = byte(acc >> nbits)
+			j++
+		}
+	}
+	var ms int64
+	for _, c := range raw[:6] {
+		ms = ms<<8 | int64(c)
+	}
+	p := ParsedULID{Time: time.UnixMilli(ms)}
+	copy(p.Entropy[:], raw[6:])
+	return p, nil
+}
@@ -0,0 +1,81 @@
+package crockford_test
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+// ulidString builds a ULID independently with math/big
+func ulidString(ms int64, entropy [10]byte) string {
+	var raw [16]byte
+	for i := 0; i < 6; i++ {
+		raw[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(raw[6:], entropy[:])
+	digits := new(big.Int).SetBytes(raw[:]).Text(32)
+	// big uses 0-9a-v for base 32
+	var sb strings.Builder
+	for i := len(digits); i < crockford.LenULID; i++ {
+		sb.WriteByte('0')
+	}
+	for i := 0; i < len(digits); i++ {
+		v := strings.IndexByte("0123456789abcdefghijklmnopqrstuv", digits[i])
+		sb.WriteByte(crockford.UppercaseAlphabet[v])
+	}
+	return sb.String()
+}
+
+func TestParseULIDStruct(t *testing.T) {
+	// from the ULID specification
+	p, err := crockford.ParseULIDStruct(crockford.Upper, "01ARYZ6S41TSV4RRFFQ69G5FAV")
+	be.NilErr(t, err)
+	be.Equal(t, int64(1469918176385), p.Time.UnixMilli())
+
+	for _, tc := range []struct {
+		ms      int64
+		entropy [10]byte
+	}{
+		{0, [10]byte{}},
+		{1<<48 - 1, [10]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{time.Date(2020, 1, 1, 0, 0, 0, 123e6, time.UTC).UnixMilli(), [10]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	} {
+		s := ulidString(tc.ms, tc.entropy)
+		be.Equal(t, crockford.LenULID, len(s))
+		for _, in := range []string{s, strings.ToLower(s)} {
+			p, err := crockford.ParseULIDStruct(crockford.Lower, in)
+			be.NilErr(t, err)
+			be.Equal(t, tc.ms, p.Time.UnixMilli())
+			be.Equal(t, tc.entropy, p.Entropy)
+		}
+	}
+	be.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", ulidString(1<<48-1, [10]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}))
+
+	_, err = crockford.ParseULIDStruct(crockford.Upper, "8ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	be.True(t, errors.Is(err, crockford.ErrOverflow))
+	for _, in := range []string{"", "01ARYZ6S41TSV4RRFFQ69G5FA", "01ARYZ6S41TSV4RRFFQ69G5FAVV"} {
+		_, err = crockford.ParseULIDStruct(crockford.Upper, in)
+		be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+	}
+	_, err = crockford.ParseULIDStruct(crockford.Upper, "01ARYZ6S41TSV4RRFFQ69G5FA*")
+	be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+}
+
+func TestLooksTruncated(t *testing.T) {
+	const ulid = "01ARYZ6S41TSV4RRFFQ69G5FAV"
+	be.False(t, crockford.LooksTruncated(ulid, crockford.LenULID))
+	be.False(t, crockford.LooksTruncated(strings.ToLower(ulid), crockford.LenULID))
+	be.False(t, crockford.LooksTruncated(crockford.Partition(ulid, 4), crockford.LenULID))
+	be.True(t, crockford.LooksTruncated(ulid[:25], crockford.LenULID))
+	be.True(t, crockford.LooksTruncated(crockford.Partition(ulid, 4)[:30], crockford.LenULID))
+	be.True(t, crockford.LooksTruncated("", crockford.LenULID))
+	// a truncated ULID can still decode
+	_, err := crockford.DecodeString(crockford.Upper, ulid[:24])
+	be.NilErr(t, err)
+	be.True(t, crockford.LooksTruncated(ulid[:24], crockford.LenULID))
+}
@@ -0,0 +1,78 @@
+package crockford
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+)
+
+// AppendUUIDString parses a hyphenated hex UUID such as
+// "123e4567-e89b-12d3-a456-426614174000" in either case
+// and appends the encoding of its 16 bytes onto dst.
+// It returns an error if uuid is not in the 8-4-4-4-12 form, matching
+// ErrInvalidLength if uuid is not 36 bytes long and ErrInvalidChar otherwise.
+func AppendUUIDString(e *base32.Encoding, uuid string, dst []byte) ([]byte, error) {
+	var b [16]byte
+	if err := parseUUID(&b, uuid); err != nil {
+		return dst, err
+	}
+	return AppendBytes16(e, b, dst), nil
+}
+
+func parseUUID(b *[16]byte, uuid string) error {
+	if len(uuid) != 36 {
+		return fmt.Errorf("%w: UUID %q", ErrInvalidLength, uuid)
+	}
+	if uuid[8] != '-' || uuid[13] != '-' || uuid[18] != '-' || uuid[23] != '-' {
+		return fmt.Errorf("%w: UUID %q", ErrInvalidChar, uuid)
+	}
+	j := 0
+	for _, i := range [...]int{0, 2, 4, 6, 9, 11, 14, 16, 19, 21, 24, 26, 28, 30, 32, 34} {
+		if _, err := hex.Decode(b[j:j+1], []byte(uuid[i:i+2])); err != nil {
+			return fmt.Errorf("%w: UUID %q", ErrInvalidChar, uuid)
+		}
+		j++
+	}
+	return nil
+}
+
+// ToUUIDString decodes s, which must hold 16 bytes such as those
+// encoded by AppendUUIDString, and formats them as a lowercase
+// hyphenated hex UUID in the 8-4-4-4-12 form.
+func ToUUIDString(e *base32.Encoding, s string) (string, error) {
+	b, err := DecodeString(e, s)
+	if err != nil {
+		return "", err
+	}
+	if len(b) != 16 {
+		return "", lenError(len(b), 16)
+	}
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:36], b[10:16])
+	return string(buf[:]), nil
+}
+
+// DecodeUUIDInto decodes s, which must hold 16 bytes such as those
+// encoded by AppendUUIDString, into out. It does not allocate
+// for inputs of up to 48 bytes, such as a partitioned UUID.
+func DecodeUUIDInto(e *base32.Encoding, s string, out *[16]byte) error {
+	// room for the decoded bytes and the normalized scratch of AppendDecoded
+	var buf [80]byte
+	b, err := AppendDecoded(e, buf[:0], []byte(s))
+	if err != nil {
+		return err
+	}
+	if len(b) != 16 {
+		return lenError(len(b), 16)
+	}
+	copy(out[:], b)
+	return nil
+}
@@ -0,0 +1,99 @@
+package crockford_test
+
+import (
+	"encoding/base32"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func TestAppendUUIDString(t *testing.T) {
+	want := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	for _, in := range []string{
+		"123e4567-e89b-12d3-a456-426614174000",
+		"123E4567-E89B-12D3-A456-426614174000",
+		"123e4567-E89B-12d3-A456-426614174000",
+	} {
+		got, err := crockford.AppendUUIDString(crockford.Upper, in, []byte("x"))
+		be.NilErr(t, err)
+		be.Equal(t, "x"+string(crockford.AppendBytes16(crockford.Upper, want, nil)), string(got))
+	}
+	for _, in := range []string{
+		"",
+		"123e4567e89b12d3a456426614174000",
+		"123e4567-e89b12d3-a456-426614174000",
+		"123e4567-e89b-12d3-a456-4266-14174000",
+		"123e4567--e89b-12d3-a456-426614174000",
+		"123e4567-e89b-12d3-a456-42661417400g",
+		"{123e4567-e89b-12d3-a456-426614174000}",
+	} {
+		got, err := crockford.AppendUUIDString(crockford.Upper, in, []byte("x"))
+		be.Nonzero(t, err)
+		be.Equal(t, "x", string(got))
+	}
+}
+
+func TestToUUIDString(t *testing.T) {
+	for _, uuid := range []string{
+		"123e4567-e89b-12d3-a456-426614174000",
+		"00000000-0000-0000-0000-000000000000",
+		"ffffffff-ffff-ffff-ffff-ffffffffffff",
+	} {
+		for _, e := range []*base32.Encoding{crockford.Upper, crockford.Lower} {
+			s, err := crockford.AppendUUIDString(e, strings.ToUpper(uuid), nil)
+			be.NilErr(t, err)
+			be.Equal(t, crockford.LenUUID, len(s))
+			got, err := crockford.ToUUIDString(e, crockford.Partition(string(s), 4))
+			be.NilErr(t, err)
+			be.Equal(t, uuid, got)
+		}
+	}
+	_, err := crockford.ToUUIDString(crockford.Upper, crockford.Upper.EncodeToString(make([]byte, 15)))
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+	_, err = crockford.ToUUIDString(crockford.Upper, "*")
+	be.Nonzero(t, err)
+
+	for _, tc := range []struct {
+		in   string
+		want error
+	}{
+		{"123e4567-e89b-12d3-a456-42661417400", crockford.ErrInvalidLength},
+		{"123e4567-e89b-12d3-a456-4266141740000", crockford.ErrInvalidLength},
+		{"123e4567e-89b-12d3-a456-426614174000", crockford.ErrInvalidChar},
+		{"123e4567-e89b-12d3-a456-42661417400g", crockford.ErrInvalidChar},
+	} {
+		_, err = crockford.AppendUUIDString(crockford.Upper, tc.in, nil)
+		be.True(t, errors.Is(err, tc.want))
+	}
+}
+
+func TestDecodeUUIDInto(t *testing.T) {
+	want := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	s := string(crockford.AppendBytes16(crockford.Upper, want, nil))
+	var got [16]byte
+	be.NilErr(t, crockford.DecodeUUIDInto(crockford.Lower, strings.ToLower(s), &got))
+	be.Equal(t, want, got)
+
+	partitioned := crockford.Partition(s, 4)
+	var err error
+	allocs := testing.AllocsPerRun(100, func() {
+		err = crockford.DecodeUUIDInto(crockford.Upper, partitioned, &got)
+	})
+	be.Zero(t, allocs)
+	be.NilErr(t, err)
+	be.Equal(t, want, got)
+	padded := partitioned + strings.Repeat("-", 48-len(partitioned))
+	allocs = testing.AllocsPerRun(100, func() {
+		err = crockford.DecodeUUIDInto(crockford.Upper, padded, &got)
+	})
+	be.Zero(t, allocs)
+	be.NilErr(t, err)
+
+	err = crockford.DecodeUUIDInto(crockford.Upper, s[:24], &got)
+	be.True(t, errors.Is(err, crockford.ErrInvalidLength))
+	err = crockford.DecodeUUIDInto(crockford.Upper, s[:25]+"*", &got)
+	be.True(t, errors.Is(err, crockford.ErrInvalidChar))
+}
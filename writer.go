@@ -0,0 +1,48 @@
+package crockford
+
+import (
+	"encoding/base32"
+	"io"
+)
+
+// Writer is a streaming encoder returned by NewEncoder.
+type Writer struct {
+	e   *base32.Encoding
+	w   io.Writer
+	enc io.WriteCloser
+	ck  Checksummer
+}
+
+// NewEncoder returns a Writer that encodes bytes written to it with e
+// and writes the result to w. The caller must Close the Writer
+// to flush any partially written blocks.
+func NewEncoder(e *base32.Encoding, w io.Writer) *Writer {
+	return &Writer{
+		e:   e,
+		w:   w,
+		enc: base32.NewEncoder(e, w),
+	}
+}
+
+// Write encodes p onto the underlying writer,
+// updating the running checksum of the raw bytes.
+func (cw *Writer) Write(p []byte) (n int, err error) {
+	n, err = cw.enc.Write(p)
+	cw.ck.Write(p[:n])
+	return n, err
+}
+
+// Close flushes any pending output.
+func (cw *Writer) Close() error {
+	return cw.enc.Close()
+}
+
+// CloseWithChecksum flushes any pending output and then writes the symbol
+// Checksum would return for all of the bytes written.
+func (cw *Writer) CloseWithChecksum() error {
+	if err := cw.enc.Close(); err != nil {
+		return err
+	}
+	_, err := cw.w.Write([]byte{cw.ck.Sum(isUpper(cw.e))})
+	return err
+}
@@ -0,0 +1,41 @@
+package crockford_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/be"
+	"github.com/carlmjohnson/crockford"
+)
+
+func TestWriter(t *testing.T) {
+	var buf strings.Builder
+	w := crockford.NewEncoder(crockford.Lower, &buf)
+	_, err := io.WriteString(w, "hello world")
+	be.NilErr(t, err)
+	be.NilErr(t, w.Close())
+	be.Equal(t, "d1jprv3f41vpywkccg", buf.String())
+}
+
+func TestWriterChecksum(t *testing.T) {
+	payload := bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog. "), 10_000)
+	var buf strings.Builder
+	w := crockford.NewEncoder(crockford.Upper, &buf)
+	// stream in uneven chunks
+	for rest := payload; len(rest) > 0; {
+		n := 7777
+		if n > len(rest) {
+			n = len(rest)
+		}
+		_, err := w.Write(rest[:n])
+		be.NilErr(t, err)
+		rest = rest[n:]
+	}
+	be.NilErr(t, w.CloseWithChecksum())
+
+	want := crockford.Upper.EncodeToString(payload) + string(crockford.Checksum(payload, true))
+	be.Equal(t, want, buf.String())
+	be.True(t, crockford.HasValidChecksum(crockford.Upper, buf.String()))
+}